@@ -0,0 +1,583 @@
+// Command fem-solve computes body deformation without opening a window and writes displaced nodes.
+//
+// Usage:
+//
+//	fem-solve [-scenario file.json] [-size 4,5,3] [-split 4,8,3] [-young 4] [-poisson 0.3] [-pressure 2] \
+//		[-pressure-field 2+0.5*z] [-material 20,0.25] [-layer-material 2:1] [-density 0] [-acceleration 0,0,-9.81] \
+//		[-fixed bottom] [-pushed top] [-displace top:,,0.1] [-traction right:0,0,0.5] \
+//		[-traction-local right:0,0.5,0] [-load 12:0,0,-1] [-load 4,5,3:0,0,-1] [-moment-point 0,0,0] \
+//		[-solver cg] [-constraints penalty] [-precon ic0] [-tol 1e-8] [-max-iter 0] \
+//		[-save-scenario file.json] [-vtk result.vtu] [-o output.txt]
+//
+// Faces are named left, right (min and max x), front, back (min and max y), bottom and top (min and max z). Fixed
+// faces can be followed by fixed axes, like left:x for symmetry plane, all axes are fixed otherwise. Values from flags
+// override values from the scenario file. Displaced faces are followed by displacement components, empty components
+// are left free. Traction is given by global x, y and z components or, for local traction, by normal (outward) and two
+// tangential components along global axes following the normal one cyclically. Self-weight is applied with density
+// and acceleration of the body. Concentrated load is applied to node given by its index or to the node nearest to
+// given x, y and z coords. Pressure field is an expression of x, y and z with operators + - * / ^, parentheses,
+// constant pi and functions abs, sqrt, exp, log, sin, cos, tan, min and max. Extra materials are numbered from 1 in
+// order of -material flags after ones of the scenario, main material given by -young and -poisson has number 0, layers
+// of elements along z are numbered from 0 at the bottom. Orthotropic material given by flag uses global axes as
+// material ones, materials with other axes are set in the scenario.
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mymmrac/go-fem-body-deformation/fem"
+)
+
+var faceNames = []string{"left", "right", "front", "back", "bottom", "top"}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("fem-solve", flag.ContinueOnError)
+	scenarioFile := flags.String("scenario", "", "Scenario file with simulation setup")
+	saveScenarioFile := flags.String("save-scenario", "", "Save resulting scenario to the file")
+	output := flags.String("o", "", "Output file, stdout if empty")
+	vtkFile := flags.String("vtk", "", "Export mesh and results for ParaView, XML format for .vtu and legacy otherwise")
+	size := flags.String("size", "", "Size of the body `x,y,z`")
+	split := flags.String("split", "", "Number of elements along each axis `x,y,z`")
+	young := flags.Float64("young", 0, "Young's modulus")
+	poisson := flags.Float64("poisson", 0, "Poisson's ratio")
+	pressure := flags.Float64("pressure", 0, "Pressure applied to pushed faces")
+	pressureField := flags.String("pressure-field", "", "Pressure `expression` of x, y and z applied to pushed faces "+
+		"instead of constant one, like 2+0.5*z")
+	density := flags.Float64("density", 0, "Density of the body, body force is density times acceleration")
+	acceleration := flags.String("acceleration", "0,0,-9.81", "Acceleration `x,y,z` of the body, gravity by default")
+	fixed := flags.String("fixed", "", "Comma separated list of fixed `faces`, optionally with axes like left:x")
+	pushed := flags.String("pushed", "", "Comma separated list of pushed `faces`")
+	var tractions, localTractions []string
+	flags.Func("traction", "Traction `face:x,y,z` in global axes applied to the face, can be repeated",
+		func(s string) error {
+			tractions = append(tractions, s)
+			return nil
+		})
+	flags.Func("traction-local", "Traction `face:n,t1,t2` with normal and tangential components applied to the "+
+		"face, can be repeated", func(s string) error {
+		localTractions = append(localTractions, s)
+		return nil
+	})
+	var materials, layerMaterials []string
+	flags.Func("material", "Extra material `young,poisson` numbered from 1, or orthotropic:e1,e2,e3,nu12,nu13,nu23,"+
+		"g12,g13,g23, or anisotropic: with 21 values of upper half of constitutive matrix, can be repeated",
+		func(s string) error {
+			materials = append(materials, s)
+			return nil
+		})
+	flags.Func("layer-material", "Material `layer:material` of layer of elements along z, can be repeated",
+		func(s string) error {
+			layerMaterials = append(layerMaterials, s)
+			return nil
+		})
+	var loads []string
+	flags.Func("load", "Concentrated force `node:fx,fy,fz` applied to node by index or nearest to x,y,z coords, "+
+		"can be repeated", func(s string) error {
+		loads = append(loads, s)
+		return nil
+	})
+	momentPoint := flags.String("moment-point", "0,0,0", "Point `x,y,z` about which moments of reactions are computed")
+	var displaced []string
+	flags.Func("displace", "Prescribed displacement of the face like `top:,,0.1`, empty components are free, "+
+		"can be repeated", func(s string) error {
+		displaced = append(displaced, s)
+		return nil
+	})
+	solverMethod := flags.String("solver", fem.SolverCG.String(), "Linear system solver: cg or cholesky")
+	constraints := flags.String("constraints", fem.ConstraintPenalty.String(),
+		"Method of fixing unknowns: penalty or elimination")
+	precon := flags.String("precon", fem.PreconditionerIncompleteCholesky.String(),
+		"Preconditioner of the solver: none, jacobi, block-jacobi or ic0")
+	tolerance := flags.Float64("tol", 0, "Relative residual tolerance of the solver, 1e-8 if zero")
+	maxIterations := flags.Int("max-iter", 0, "Limit of solver iterations, 4 times number of unknowns if zero")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	scenario := &fem.Scenario{
+		Version: fem.ScenarioVersion,
+		Size:    [3]float64{4, 5, 3},
+		Split:   [3]int{4, 8, 3},
+		Material: fem.Material{
+			YoungsModulus: 4,
+			PoissonRatio:  0.3,
+		},
+		Pressure: 2,
+	}
+	fixedFaces := []string{"bottom"}
+	pushedFaces := []string{"top"}
+
+	var err error
+	if *scenarioFile != "" {
+		scenario, err = fem.LoadScenario(*scenarioFile)
+		if err != nil {
+			return err
+		}
+		// Older versions are subset of the current one, so scenario is saved in the current version
+		scenario.Version = fem.ScenarioVersion
+		fixedFaces, pushedFaces = nil, nil
+	}
+
+	// Body force flag that isn't given keeps value from the scenario, acceleration defaults to gravity
+	bodyForce := func() *fem.BodyForce {
+		if scenario.BodyForce == nil {
+			scenario.BodyForce = &fem.BodyForce{Acceleration: [3]float64{0, 0, -9.81}}
+		}
+		return scenario.BodyForce
+	}
+	flags.Visit(func(f *flag.Flag) {
+		if err != nil {
+			return
+		}
+		switch f.Name {
+		case "size":
+			scenario.Size, err = parseVec3(*size, func(s string) (float64, error) { return strconv.ParseFloat(s, 64) })
+		case "split":
+			scenario.Split, err = parseVec3(*split, strconv.Atoi)
+		case "young":
+			err = isotropic(scenario.Material)
+			scenario.Material.YoungsModulus = *young
+		case "poisson":
+			err = isotropic(scenario.Material)
+			scenario.Material.PoissonRatio = *poisson
+		case "pressure":
+			scenario.Pressure = *pressure
+			scenario.PressureField = nil
+		case "pressure-field":
+			scenario.PressureField = nil
+			if *pressureField != "" {
+				scenario.PressureField, err = fem.ParseExpression(*pressureField)
+			}
+		case "density":
+			bodyForce().Density = *density
+		case "acceleration":
+			bodyForce().Acceleration, err = parseVec3(*acceleration,
+				func(s string) (float64, error) { return strconv.ParseFloat(s, 64) })
+		case "fixed":
+			fixedFaces = parseList(*fixed)
+			scenario.Fixed = nil
+		case "pushed":
+			pushedFaces = parseList(*pushed)
+			scenario.Pushed = nil
+		}
+		if err != nil {
+			err = fmt.Errorf("invalid -%s: %w", f.Name, err)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = scenario.Validate(); err != nil {
+		return err
+	}
+
+	method, err := fem.ParseSolverMethod(*solverMethod)
+	if err != nil {
+		return fmt.Errorf("invalid -solver: %w", err)
+	}
+	constraintMethod, err := fem.ParseConstraintMethod(*constraints)
+	if err != nil {
+		return fmt.Errorf("invalid -constraints: %w", err)
+	}
+	point, err := parseVec3(*momentPoint, func(s string) (float64, error) { return strconv.ParseFloat(s, 64) })
+	if err != nil {
+		return fmt.Errorf("invalid -moment-point: %w", err)
+	}
+	preconditioner, err := fem.ParsePreconditioner(*precon)
+	if err != nil {
+		return fmt.Errorf("invalid -precon: %w", err)
+	}
+	if *tolerance < 0 || *tolerance >= 1 {
+		return fmt.Errorf("invalid -tol: %g is not in [0, 1)", *tolerance)
+	}
+
+	mesh, err := scenario.Mesh()
+	if err != nil {
+		return err
+	}
+
+	for _, face := range fixedFaces {
+		name, axesName, hasAxes := strings.Cut(face, ":")
+		axes := fem.AxesAll
+		if hasAxes {
+			axes, err = fem.ParseAxes(axesName)
+			if err != nil {
+				return fmt.Errorf("invalid axes of fixed face %q: %w", name, err)
+			}
+		}
+
+		fixedSides, err := faceSides(mesh, []string{name})
+		if err != nil {
+			return err
+		}
+		for _, es := range fixedSides {
+			scenario.Fixed = append(scenario.Fixed, fem.FixedSide{ElementSide: es, Axes: axes})
+		}
+	}
+
+	pushedSides, err := faceSides(mesh, pushedFaces)
+	if err != nil {
+		return err
+	}
+	scenario.Pushed = append(scenario.Pushed, pushedSides...)
+
+	for _, d := range displaced {
+		sides, err := displacedFaceSides(mesh, d)
+		if err != nil {
+			return fmt.Errorf("invalid -displace: %w", err)
+		}
+		scenario.Displaced = append(scenario.Displaced, sides...)
+	}
+
+	for _, list := range []struct {
+		values []string
+		local  bool
+		flag   string
+	}{{tractions, false, "traction"}, {localTractions, true, "traction-local"}} {
+		for _, t := range list.values {
+			sides, err := tractionFaceSides(mesh, t, list.local)
+			if err != nil {
+				return fmt.Errorf("invalid -%s: %w", list.flag, err)
+			}
+			scenario.Tractions = append(scenario.Tractions, sides...)
+		}
+	}
+
+	for _, m := range materials {
+		material, err := parseMaterial(m)
+		if err != nil {
+			return fmt.Errorf("invalid -material: %w", err)
+		}
+		scenario.Materials = append(scenario.Materials, material)
+	}
+	for _, lm := range layerMaterials {
+		elementMaterials, err := layerElementMaterials(mesh, lm, len(scenario.Materials))
+		if err != nil {
+			return fmt.Errorf("invalid -layer-material: %w", err)
+		}
+		scenario.ElementMaterials = append(scenario.ElementMaterials, elementMaterials...)
+	}
+
+	for _, l := range loads {
+		load, err := nodalLoad(mesh, l)
+		if err != nil {
+			return fmt.Errorf("invalid -load: %w", err)
+		}
+		scenario.NodalLoads = append(scenario.NodalLoads, load)
+	}
+
+	if *saveScenarioFile != "" {
+		if err = scenario.Save(*saveScenarioFile); err != nil {
+			return err
+		}
+	}
+
+	solver := fem.New(mesh)
+	solver.Method = method
+	solver.Constraints = constraintMethod
+	solver.Preconditioner = preconditioner
+	solver.Tolerance = *tolerance
+	solver.MaxIterations = *maxIterations
+	result, err := solver.SolveMaterials(context.Background(), scenario.MaterialsTable(), scenario.BoundaryConditions())
+	if err != nil {
+		return err
+	}
+	if !result.Balanced() {
+		_, _ = fmt.Fprintf(os.Stderr, "Warning: equilibrium error %g is too large, result is inaccurate\n",
+			result.EquilibriumError())
+	}
+
+	if *vtkFile != "" {
+		if err = fem.SaveVTK(*vtkFile, result); err != nil {
+			return err
+		}
+	}
+
+	out := stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("create output: %w", err)
+		}
+		defer func() { _ = file.Close() }()
+		out = file
+	}
+
+	if len(scenario.Displaced) > 0 || len(scenario.DisplacedNodes) > 0 {
+		r := result.DisplacementReaction()
+		_, _ = fmt.Fprintf(out, "# displacement reaction %g %g %g\n", r[0], r[1], r[2])
+	}
+	writeReactions(out, result, point)
+	if err = writeResult(out, result); err != nil {
+		return fmt.Errorf("write output: %w", err)
+	}
+	return nil
+}
+
+// isotropic returns error if main material isn't isotropic, so its Young's modulus and Poisson's ratio aren't used
+func isotropic(material fem.Material) error {
+	if material.Type != fem.MaterialIsotropic {
+		return fmt.Errorf("main material of the scenario is %s, only isotropic one is given by -young and -poisson",
+			material.Type)
+	}
+	return nil
+}
+
+func parseVec3[T int | float64](s string, parse func(string) (T, error)) ([3]T, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 3 {
+		return [3]T{}, fmt.Errorf("expected 3 comma separated values, got %q", s)
+	}
+
+	var v [3]T
+	for i, part := range parts {
+		var err error
+		v[i], err = parse(strings.TrimSpace(part))
+		if err != nil {
+			return [3]T{}, err
+		}
+	}
+	return v, nil
+}
+
+func parseList(s string) []string {
+	var list []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			list = append(list, part)
+		}
+	}
+	return list
+}
+
+func faceSides(mesh *fem.Mesh, faces []string) ([]fem.ElementSide, error) {
+	var sides []fem.ElementSide
+	for _, face := range faces {
+		side := slices.Index(faceNames, face)
+		if side == -1 {
+			return nil, fmt.Errorf("unknown face %q, expected one of %s", face, strings.Join(faceNames, ", "))
+		}
+		sides = append(sides, mesh.FaceSides(side)...)
+	}
+	return sides, nil
+}
+
+// displacedFaceSides parses face with displacement components like top:,,0.1
+func displacedFaceSides(mesh *fem.Mesh, s string) ([]fem.DisplacedSide, error) {
+	face, components, ok := strings.Cut(s, ":")
+	if !ok {
+		return nil, fmt.Errorf("expected face:x,y,z, got %q", s)
+	}
+	parts := strings.Split(components, ",")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("expected 3 comma separated components, got %q", components)
+	}
+
+	var d fem.Displacement
+	for i, part := range parts {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return nil, err
+		}
+		d.Axes |= 1 << i
+		d.Value[i] = v
+	}
+	if d.Axes == 0 {
+		return nil, fmt.Errorf("no displacement components in %q", s)
+	}
+
+	sides, err := faceSides(mesh, []string{face})
+	if err != nil {
+		return nil, err
+	}
+	displacedSides := make([]fem.DisplacedSide, len(sides))
+	for i, es := range sides {
+		displacedSides[i] = fem.DisplacedSide{ElementSide: es, Displacement: d}
+	}
+	return displacedSides, nil
+}
+
+// tractionFaceSides parses face with traction components like right:0,0,0.5
+func tractionFaceSides(mesh *fem.Mesh, s string, local bool) ([]fem.TractionSide, error) {
+	face, components, ok := strings.Cut(s, ":")
+	if !ok {
+		return nil, fmt.Errorf("expected face:x,y,z, got %q", s)
+	}
+	vector, err := parseVec3(components, func(s string) (float64, error) { return strconv.ParseFloat(s, 64) })
+	if err != nil {
+		return nil, err
+	}
+
+	sides, err := faceSides(mesh, []string{face})
+	if err != nil {
+		return nil, err
+	}
+	tractionSides := make([]fem.TractionSide, len(sides))
+	for i, es := range sides {
+		tractionSides[i] = fem.TractionSide{ElementSide: es, Traction: fem.Traction{Vector: vector, Local: local}}
+	}
+	return tractionSides, nil
+}
+
+// parseMaterial parses isotropic material like 1,0.25 or other type of material followed by its constants like
+// orthotropic:5,10,2,0.2,0.4,0.1,1,1,1
+func parseMaterial(s string) (fem.Material, error) {
+	typeName, constants, ok := strings.Cut(s, ":")
+	if !ok {
+		typeName, constants = fem.MaterialIsotropic.String(), s
+	}
+	materialType, err := fem.ParseMaterialType(typeName)
+	if err != nil {
+		return fem.Material{}, err
+	}
+
+	var values []float64
+	for _, part := range strings.Split(constants, ",") {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return fem.Material{}, err
+		}
+		values = append(values, v)
+	}
+
+	material := fem.Material{Type: materialType}
+	expected := map[fem.MaterialType]int{
+		fem.MaterialIsotropic:   2,
+		fem.MaterialOrthotropic: 9,
+		fem.MaterialAnisotropic: 21,
+	}
+	if len(values) != expected[materialType] {
+		return fem.Material{}, fmt.Errorf("%s material expects %d constants, got %d", materialType,
+			expected[materialType], len(values))
+	}
+	switch materialType {
+	case fem.MaterialIsotropic:
+		material.YoungsModulus, material.PoissonRatio = values[0], values[1]
+	case fem.MaterialOrthotropic:
+		material.Orthotropic = fem.Orthotropic{
+			E1: values[0], E2: values[1], E3: values[2],
+			Nu12: values[3], Nu13: values[4], Nu23: values[5],
+			G12: values[6], G13: values[7], G23: values[8],
+		}
+	case fem.MaterialAnisotropic:
+		// Upper half is given row by row
+		next := 0
+		for i := range 6 {
+			for j := i; j < 6; j++ {
+				material.Stiffness[i][j], material.Stiffness[j][i] = values[next], values[next]
+				next++
+			}
+		}
+	}
+	return material, material.Validate()
+}
+
+// layerElementMaterials parses layer along z with material number like 2:1, there are extra materials besides the
+// main one
+func layerElementMaterials(mesh *fem.Mesh, s string, extra int) ([]fem.ElementMaterial, error) {
+	layerText, materialText, ok := strings.Cut(s, ":")
+	if !ok {
+		return nil, fmt.Errorf("expected layer:material, got %q", s)
+	}
+	layer, err := strconv.Atoi(layerText)
+	if err != nil {
+		return nil, err
+	}
+	if layer < 0 || layer >= mesh.Split()[2] {
+		return nil, fmt.Errorf("layer %d is out of range [0, %d)", layer, mesh.Split()[2])
+	}
+	material, err := strconv.Atoi(materialText)
+	if err != nil {
+		return nil, err
+	}
+	if material < 0 || material > extra {
+		return nil, fmt.Errorf("material %d is out of range [0, %d]", material, extra)
+	}
+
+	var elementMaterials []fem.ElementMaterial
+	for _, element := range mesh.LayerElements(2, layer) {
+		elementMaterials = append(elementMaterials, fem.ElementMaterial{Element: element, Material: material})
+	}
+	return elementMaterials, nil
+}
+
+// nodalLoad parses node index or coords of the nearest node with force components like 12:0,0,-1 or 4,5,3:0,0,-1
+func nodalLoad(mesh *fem.Mesh, s string) (fem.NodalLoad, error) {
+	node, components, ok := strings.Cut(s, ":")
+	if !ok {
+		return fem.NodalLoad{}, fmt.Errorf("expected node:fx,fy,fz, got %q", s)
+	}
+	force, err := parseVec3(components, func(s string) (float64, error) { return strconv.ParseFloat(s, 64) })
+	if err != nil {
+		return fem.NodalLoad{}, err
+	}
+
+	load := fem.NodalLoad{Force: force}
+	if strings.Contains(node, ",") {
+		point, err := parseVec3(node, func(s string) (float64, error) { return strconv.ParseFloat(s, 64) })
+		if err != nil {
+			return fem.NodalLoad{}, err
+		}
+		load.Node = mesh.NearestNode(point)
+	} else {
+		load.Node, err = strconv.Atoi(node)
+		if err != nil {
+			return fem.NodalLoad{}, err
+		}
+		if load.Node < 0 || load.Node >= len(mesh.Nodes()) {
+			return fem.NodalLoad{}, fmt.Errorf("node %d is out of range [0, %d)", load.Node, len(mesh.Nodes()))
+		}
+	}
+	return load, nil
+}
+
+// writeReactions writes resultant reactions of constrained faces with moments about the point, applied load and
+// equilibrium error as comments
+func writeReactions(w io.Writer, result *fem.Result, point [3]float64) {
+	_, _ = fmt.Fprintf(w, "# reactions about %g %g %g: face fx fy fz mx my mz\n", point[0], point[1], point[2])
+	for _, r := range result.FaceReactions(point) {
+		face := "nodes"
+		if r.Side >= 0 {
+			face = faceNames[r.Side]
+		}
+		_, _ = fmt.Fprintf(w, "# reaction %s %g %g %g %g %g %g\n", face,
+			r.Force[0], r.Force[1], r.Force[2], r.Moment[0], r.Moment[1], r.Moment[2])
+	}
+	load := result.AppliedLoad()
+	_, _ = fmt.Fprintf(w, "# applied load %g %g %g\n", load[0], load[1], load[2])
+	_, _ = fmt.Fprintf(w, "# equilibrium error %g\n", result.EquilibriumError())
+}
+
+// writeResult writes displaced coords and displacement of each node, one node per line
+func writeResult(w io.Writer, result *fem.Result) error {
+	bw := bufio.NewWriter(w)
+	_, _ = fmt.Fprintln(bw, "# node x y z ux uy uz")
+	for i, p := range result.DeformedNodes() {
+		u := result.Displacement(i)
+		_, _ = fmt.Fprintf(bw, "%d %g %g %g %g %g %g\n", i, p[0], p[1], p[2], u[0], u[1], u[2])
+	}
+	return bw.Flush()
+}
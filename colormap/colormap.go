@@ -0,0 +1,39 @@
+// Package colormap maps scalar values to colors for drawing result contours
+package colormap
+
+import (
+	"image/color"
+	"math"
+)
+
+// colorMapStops are colors of the color map evenly spread from min to max value
+var colorMapStops = [...]color.RGBA{
+	{R: 0, G: 0, B: 255, A: 255},
+	{R: 0, G: 255, B: 255, A: 255},
+	{R: 0, G: 255, B: 0, A: 255},
+	{R: 255, G: 255, B: 0, A: 255},
+	{R: 255, G: 0, B: 0, A: 255},
+}
+
+// Color maps value from [minValue, maxValue] range to the color going from blue through cyan, green and yellow to
+// red, values outside the range are clamped, if range is empty middle color is used and NaN is mapped to gray
+func Color(value, minValue, maxValue float64) color.RGBA {
+	if math.IsNaN(value) {
+		return color.RGBA{R: 128, G: 128, B: 128, A: 255}
+	}
+
+	t := 0.5
+	if maxValue > minValue {
+		t = max(min((value-minValue)/(maxValue-minValue), 1), 0)
+	}
+
+	pos := t * float64(len(colorMapStops)-1)
+	i := min(int(pos), len(colorMapStops)-2)
+	frac := pos - float64(i)
+
+	from, to := colorMapStops[i], colorMapStops[i+1]
+	lerp := func(a, b uint8) uint8 {
+		return uint8(math.Round(float64(a) + (float64(b)-float64(a))*frac))
+	}
+	return color.RGBA{R: lerp(from.R, to.R), G: lerp(from.G, to.G), B: lerp(from.B, to.B), A: 255}
+}
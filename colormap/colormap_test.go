@@ -0,0 +1,38 @@
+package colormap
+
+import (
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestColor(t *testing.T) {
+	gray := color.RGBA{R: 128, G: 128, B: 128, A: 255}
+
+	tests := []struct {
+		name              string
+		value, minV, maxV float64
+		expected          color.RGBA
+	}{
+		{"min", -2, -2, 6, colorMapStops[0]},
+		{"max", 6, -2, 6, colorMapStops[4]},
+		{"cyan stop", 0, -2, 6, colorMapStops[1]},
+		{"green stop", 2, -2, 6, colorMapStops[2]},
+		{"yellow stop", 4, -2, 6, colorMapStops[3]},
+		{"between stops", 3, -2, 6, color.RGBA{R: 128, G: 255, B: 0, A: 255}},
+		{"below min", -10, -2, 6, colorMapStops[0]},
+		{"above max", 100, -2, 6, colorMapStops[4]},
+		{"empty range", 1, 1, 1, colorMapStops[2]},
+		{"empty range other value", 5, 1, 1, colorMapStops[2]},
+		{"nan", math.NaN(), -2, 6, gray},
+		{"nan empty range", math.NaN(), 1, 1, gray},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if c := Color(tt.value, tt.minV, tt.maxV); c != tt.expected {
+				t.Errorf("Color(%v, %v, %v) = %v, expected %v", tt.value, tt.minV, tt.maxV, c, tt.expected)
+			}
+		})
+	}
+}
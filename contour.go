@@ -0,0 +1,65 @@
+package main
+
+import (
+	"strconv"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+
+	"github.com/mymmrac/go-fem-body-deformation/colormap"
+	"github.com/mymmrac/go-fem-body-deformation/fem"
+)
+
+// sideTriangles splits side of 4 corners and 4 middle points into triangles
+var sideTriangles = [6][3]int{
+	{0, 4, 7}, {1, 5, 4}, {2, 6, 5}, {3, 7, 6},
+	{4, 5, 6}, {4, 6, 7},
+}
+
+// drawContour draws outer sides of the body colored by values at nodes
+func drawContour(mesh *fem.Mesh, body [][3]float64, values []float64, minValue, maxValue float64, origin rl.Vector3) {
+	rl.DisableBackfaceCulling()
+	defer rl.EnableBackfaceCulling()
+
+	for _, es := range mesh.BoundarySides() {
+		nodes := mesh.SideNodes(es)
+
+		rl.Begin(rl.Triangles)
+		for _, triangle := range sideTriangles {
+			for _, i := range triangle {
+				clr := colormap.Color(values[nodes[i]], minValue, maxValue)
+				p := transformPoint(body[nodes[i]], origin)
+				rl.Color4ub(clr.R, clr.G, clr.B, clr.A)
+				rl.Vertex3f(p.X, p.Y, p.Z)
+			}
+		}
+		rl.End()
+	}
+}
+
+// drawLegend draws vertical color bar with the field name and min/max values
+func drawLegend(bounds rl.Rectangle, field fem.Field, minValue, maxValue float64) {
+	const textSize = 20
+	const textPadding = 4
+
+	rl.DrawText(field.String(), int32(bounds.X+bounds.Width)-rl.MeasureText(field.String(), textSize),
+		int32(bounds.Y)-textSize-textPadding, textSize, rl.Black)
+
+	const steps = 32
+	stepHeight := bounds.Height / steps
+	for i := range steps {
+		top := maxValue - (maxValue-minValue)*float64(i)/steps
+		bottom := maxValue - (maxValue-minValue)*float64(i+1)/steps
+		rl.DrawRectangleGradientV(
+			int32(bounds.X), int32(bounds.Y+stepHeight*float32(i)), int32(bounds.Width), int32(stepHeight+1),
+			colormap.Color(top, minValue, maxValue), colormap.Color(bottom, minValue, maxValue),
+		)
+	}
+	rl.DrawRectangleLinesEx(bounds, 1, rl.Gray)
+
+	maxText := strconv.FormatFloat(maxValue, 'g', 4, 64)
+	minText := strconv.FormatFloat(minValue, 'g', 4, 64)
+	rl.DrawText(maxText, int32(bounds.X)-rl.MeasureText(maxText, textSize)-textPadding,
+		int32(bounds.Y), textSize, rl.Black)
+	rl.DrawText(minText, int32(bounds.X)-rl.MeasureText(minText, textSize)-textPadding,
+		int32(bounds.Y+bounds.Height)-textSize, textSize, rl.Black)
+}
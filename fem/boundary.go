@@ -0,0 +1,244 @@
+package fem
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"strings"
+)
+
+// ElementSide identifies side of the element, sides are numbered by axis and direction: 0 and 1 are sides with min
+// and max x, 2 and 3 with min and max y, 4 and 5 with min and max z
+type ElementSide struct {
+	Element int `json:"element"`
+	Side    int `json:"side"`
+}
+
+// valid checks that element side exists in the mesh with given number of elements
+func (es ElementSide) valid(elements int) bool {
+	return es.Element >= 0 && es.Element < elements && es.Side >= 0 && es.Side < 6
+}
+
+// compareSides orders sides by element and then by side
+func compareSides(a, b ElementSide) int {
+	return cmp.Or(cmp.Compare(a.Element, b.Element), cmp.Compare(a.Side, b.Side))
+}
+
+// Axes is a set of global axes, used to fix only some of displacement components
+type Axes uint8
+
+// Single axes and their combinations
+const (
+	AxisX Axes = 1 << iota
+	AxisY
+	AxisZ
+
+	AxesAll = AxisX | AxisY | AxisZ
+)
+
+const axisNames = "xyz"
+
+// NormalAxis returns axis normal to the side of the element
+func NormalAxis(side int) Axes {
+	return 1 << (side / 2)
+}
+
+// Has reports whether axis with index 0 - 2 (x, y, z) is in the set
+func (a Axes) Has(axis int) bool {
+	return a&(1<<axis) != 0
+}
+
+// String returns names of axes in the set, like "xz"
+func (a Axes) String() string {
+	var sb strings.Builder
+	for i := range 3 {
+		if a.Has(i) {
+			sb.WriteByte(axisNames[i])
+		}
+	}
+	return sb.String()
+}
+
+// ParseAxes returns set of axes from their names, like "xz"
+func ParseAxes(s string) (Axes, error) {
+	var a Axes
+	for _, c := range s {
+		i := strings.IndexRune(axisNames, c)
+		if i == -1 {
+			return 0, fmt.Errorf("unknown axis %q in %q", c, s)
+		}
+		a |= 1 << i
+	}
+	return a, nil
+}
+
+func (a Axes) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+func (a *Axes) UnmarshalText(text []byte) error {
+	var err error
+	*a, err = ParseAxes(string(text))
+	return err
+}
+
+// Displacement prescribes displacement components along axes, other components are free
+type Displacement struct {
+	Axes  Axes       `json:"axes,omitempty"`
+	Value [3]float64 `json:"value"` // Displacement along x, y and z, only components along Axes are used
+}
+
+// Traction is a force per unit area applied to a side
+type Traction struct {
+	// Global x, y and z components or, if Local is set, normal and two tangential components, where normal points
+	// outward and tangential components go along global axes following normal axis cyclically (y and z for sides
+	// with normal along x, z and x for y, x and y for z)
+	Vector [3]float64 `json:"vector"`
+	Local  bool       `json:"local,omitempty"`
+}
+
+// load returns load of the traction applied to the side with given number
+func (t Traction) load(side int) sideLoad {
+	return func(_, n [3]float64) [3]float64 {
+		area := norm(n)
+		if !t.Local {
+			return [3]float64{t.Vector[0] * area, t.Vector[1] * area, t.Vector[2] * area}
+		}
+
+		force := [3]float64{t.Vector[0] * n[0], t.Vector[0] * n[1], t.Vector[0] * n[2]}
+		normalAxis := side / 2
+		force[(normalAxis+1)%3] += t.Vector[1] * area
+		force[(normalAxis+2)%3] += t.Vector[2] * area
+		return force
+	}
+}
+
+// BodyForce is a force per unit volume acting on the whole body, like self-weight
+type BodyForce struct {
+	Density      float64    `json:"density"`      // Mass per unit volume
+	Acceleration [3]float64 `json:"acceleration"` // Acceleration of the body, like gravity (0, 0, -9.81)
+
+	// Force per unit volume at point x added to the inertial one, like centrifugal or magnetic force, optional
+	Field func(x [3]float64) [3]float64 `json:"-"`
+}
+
+// zero reports whether body force is absent
+func (b BodyForce) zero() bool {
+	return b.Field == nil && (b.Density == 0 || b.Acceleration == [3]float64{})
+}
+
+// at returns force per unit volume at point x
+func (b BodyForce) at(x [3]float64) [3]float64 {
+	force := [3]float64{b.Density * b.Acceleration[0], b.Density * b.Acceleration[1], b.Density * b.Acceleration[2]}
+	if b.Field != nil {
+		field := b.Field(x)
+		for i := range 3 {
+			force[i] += field[i]
+		}
+	}
+	return force
+}
+
+// BoundaryConditions describes how the body is fixed and loaded
+type BoundaryConditions struct {
+	Fixed    map[ElementSide]Axes // Fixed points, index of the element and side with fixed displacement components
+	Pushed   map[ElementSide]bool // Pushed points, index of the element and side
+	Pressure float64              // Pressure applied to pushed sides
+
+	// Pressure at point of pushed sides, like hydrostatic 2 + 0.5*z, used instead of Pressure when set
+	PressureField *Expression
+
+	Tractions map[ElementSide]Traction // Tractions applied to sides in addition to pressure
+	BodyForce BodyForce                // Force acting on every element
+
+	NodalLoads map[int][3]float64 // Concentrated forces applied to nodes by their indexes
+
+	// Prescribed displacements of sides and nodes, they override fixed axes of shared nodes and displacement of
+	// nodes overrides displacement of sides
+	Displaced      map[ElementSide]Displacement
+	DisplacedNodes map[int]Displacement
+}
+
+// NewBoundaryConditions creates boundary conditions without fixed, pushed, loaded and displaced sides
+func NewBoundaryConditions() *BoundaryConditions {
+	return &BoundaryConditions{
+		Fixed:          make(map[ElementSide]Axes),
+		Pushed:         make(map[ElementSide]bool),
+		Tractions:      make(map[ElementSide]Traction),
+		NodalLoads:     make(map[int][3]float64),
+		Displaced:      make(map[ElementSide]Displacement),
+		DisplacedNodes: make(map[int]Displacement),
+	}
+}
+
+// Clear removes all fixed, pushed, loaded and displaced sides and nodes
+func (bc *BoundaryConditions) Clear() {
+	clear(bc.Fixed)
+	clear(bc.Pushed)
+	clear(bc.Tractions)
+	clear(bc.NodalLoads)
+	clear(bc.Displaced)
+	clear(bc.DisplacedNodes)
+}
+
+// Clone returns deep copy of boundary conditions, so they can be changed while solve of the copy is running
+func (bc *BoundaryConditions) Clone() *BoundaryConditions {
+	return &BoundaryConditions{
+		Fixed:          maps.Clone(bc.Fixed),
+		Pushed:         maps.Clone(bc.Pushed),
+		Pressure:       bc.Pressure,
+		PressureField:  bc.PressureField,
+		Tractions:      maps.Clone(bc.Tractions),
+		BodyForce:      bc.BodyForce,
+		NodalLoads:     maps.Clone(bc.NodalLoads),
+		Displaced:      maps.Clone(bc.Displaced),
+		DisplacedNodes: maps.Clone(bc.DisplacedNodes),
+	}
+}
+
+// validate checks that all sides belong to the mesh
+func (bc *BoundaryConditions) validate(mesh *Mesh) error {
+	for es, axes := range bc.Fixed {
+		if !es.valid(len(mesh.elements)) {
+			return &SideError{ElementSide: es}
+		}
+		if axes&^AxesAll != 0 {
+			return fmt.Errorf("invalid fixed axes %08b of side %d of element %d", axes, es.Side, es.Element)
+		}
+	}
+	for es := range bc.Pushed {
+		if !es.valid(len(mesh.elements)) {
+			return &SideError{ElementSide: es}
+		}
+	}
+	for es := range bc.Tractions {
+		if !es.valid(len(mesh.elements)) {
+			return &SideError{ElementSide: es}
+		}
+	}
+	if bc.BodyForce.Density < 0 {
+		return fmt.Errorf("invalid density %g", bc.BodyForce.Density)
+	}
+	for node := range bc.NodalLoads {
+		if node < 0 || node >= len(mesh.akt) {
+			return fmt.Errorf("invalid loaded node %d", node)
+		}
+	}
+	for es, d := range bc.Displaced {
+		if !es.valid(len(mesh.elements)) {
+			return &SideError{ElementSide: es}
+		}
+		if d.Axes&^AxesAll != 0 {
+			return fmt.Errorf("invalid displaced axes %08b of side %d of element %d", d.Axes, es.Side, es.Element)
+		}
+	}
+	for node, d := range bc.DisplacedNodes {
+		if node < 0 || node >= len(mesh.akt) {
+			return fmt.Errorf("invalid displaced node %d", node)
+		}
+		if d.Axes&^AxesAll != 0 {
+			return fmt.Errorf("invalid displaced axes %08b of node %d", d.Axes, node)
+		}
+	}
+	return nil
+}
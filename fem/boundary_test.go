@@ -0,0 +1,111 @@
+package fem
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFixedSidesExact(t *testing.T) {
+	mesh, bc := newTestProblem(t)
+
+	for _, method := range ConstraintMethods {
+		t.Run(method.String(), func(t *testing.T) {
+			f := New(mesh)
+			f.Constraints = method
+			result, err := f.Solve(testMaterial, bc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Penalty leaves displacement of the order of force divided by the penalty
+			limit := 0.0
+			if method == ConstraintPenalty {
+				limit = 1e-12 * maxDisplacement(result)
+			}
+			for _, es := range mesh.FaceSides(4) {
+				for _, node := range mesh.SideNodes(es) {
+					for xyz, v := range result.Displacement(node) {
+						if math.Abs(v) > limit {
+							t.Fatalf("node %d moved by %g along axis %d", node, v, xyz)
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRollers(t *testing.T) {
+	mesh, err := NewMesh([3]float64{4, 2, 1}, [3]int{3, 2, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Body compressed along x slides on rollers and expands along y and z
+	bc := NewBoundaryConditions()
+	rollers := map[int]Axes{0: NormalAxis(0), 2: NormalAxis(2), 4: NormalAxis(4)}
+	for side, axes := range rollers {
+		for _, es := range mesh.FaceSides(side) {
+			bc.Fixed[es] = axes
+		}
+	}
+	for _, es := range mesh.FaceSides(1) {
+		bc.Pushed[es] = true
+	}
+	bc.Pressure = 2
+
+	f := New(mesh)
+	f.Constraints = ConstraintElimination
+	result, err := f.Solve(testMaterial, bc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodes := mesh.Nodes()
+	for side, axes := range rollers {
+		for _, es := range mesh.FaceSides(side) {
+			for _, node := range mesh.SideNodes(es) {
+				u := result.Displacement(node)
+				for xyz := range 3 {
+					switch {
+					case axes.Has(xyz) && u[xyz] != 0:
+						t.Fatalf("node %d moved by %g along fixed axis %d", node, u[xyz], xyz)
+					case !axes.Has(xyz) && nodes[node][xyz] > 0 && u[xyz] == 0:
+						t.Fatalf("node %d didn't move along free axis %d", node, xyz)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestAxes(t *testing.T) {
+	tests := []struct {
+		text string
+		axes Axes
+	}{
+		{"", 0},
+		{"x", AxisX},
+		{"z", AxisZ},
+		{"xy", AxisX | AxisY},
+		{"xyz", AxesAll},
+	}
+	for _, tt := range tests {
+		axes, err := ParseAxes(tt.text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if axes != tt.axes || axes.String() != tt.text {
+			t.Errorf("ParseAxes(%q) = %s, expected %s", tt.text, axes, tt.axes)
+		}
+	}
+	if _, err := ParseAxes("xw"); err == nil {
+		t.Error("unknown axis is parsed")
+	}
+
+	for side, axis := range []Axes{AxisX, AxisX, AxisY, AxisY, AxisZ, AxisZ} {
+		if a := NormalAxis(side); a != axis {
+			t.Errorf("normal axis of side %d is %s, expected %s", side, a, axis)
+		}
+	}
+}
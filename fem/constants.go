@@ -1,4 +1,4 @@
-package main
+package fem
 
 import "math"
 
@@ -19,6 +19,9 @@ var localPoints3D = [20][3]float64{
 	{0, 1, 1}, {1, 0, 1}, {0, -1, 1}, {-1, 0, 1},
 }
 
+// Approximation function in local space, 27 * 20
+var fiabg [3 * 3 * 3][20]float64
+
 // Derivative of approximation function in local space, 27 * 20 * 3 (x, y, z)
 var dfiabg [3 * 3 * 3][20][3]float64
 
@@ -28,10 +31,41 @@ var dpsite [3 * 3][8][2]float64
 // Sub integral of approximation function in local space, 3 * 3 * 8
 var dpsiteXYZdeNT [3 * 3][8]float64
 
+// Extrapolation of values from Gauss points to element vertices in local space, 20 * 27
+var gaussToNode [20][3 * 3 * 3]float64
+
 func init() {
+	calculateFIABG()
 	calculateDFIABG()
 	calculateDPSITE()
 	calculateDPsiteXYZdeNT()
+	calculateGaussToNode()
+}
+
+func calculateFIABG() {
+	for k1, gamma := range gaussianCoords {
+		for k2, beta := range gaussianCoords {
+			for k3, alpha := range gaussianCoords {
+				for i, point := range localPoints3D {
+					if i <= 7 {
+						fiabg[k1*9+k2*3+k3][i] = fiabg18(alpha, beta, gamma, point[0], point[1], point[2])
+					} else {
+						fiabg[k1*9+k2*3+k3][i] = fiabg14(alpha, beta, gamma, point[0], point[1], point[2])
+					}
+				}
+			}
+		}
+	}
+}
+
+func fiabg18(alpha, beta, gamma, x, y, z float64) float64 {
+	return (1.0 / 8.0) * (1 + alpha*x) * (1 + beta*y) * (1 + gamma*z) * (alpha*x + beta*y + gamma*z - 2)
+}
+
+func fiabg14(alpha, beta, gamma float64, alphaI, betaI, gammaI float64) float64 {
+	return (1.0 / 4.0) * (1 + alpha*alphaI) * (1 + beta*betaI) * (1 + gamma*gammaI) *
+		(1 - alpha*alpha*betaI*betaI*gammaI*gammaI - beta*beta*alphaI*alphaI*gammaI*gammaI -
+			gamma*gamma*alphaI*alphaI*betaI*betaI)
 }
 
 func calculateDFIABG() {
@@ -138,3 +172,29 @@ func psint57(eta, tau, _, y float64) float64 {
 func psint68(eta, tau, x, _ float64) float64 {
 	return (1.0 / 2.0) * (-tau*tau + 1) * (x*eta + 1)
 }
+
+// calculateGaussToNode treats Gauss points as vertices of triquadratic Lagrange element and evaluates its
+// approximation functions at element vertices
+func calculateGaussToNode() {
+	for i, point := range localPoints3D {
+		for k1 := range gaussianCoords {
+			for k2 := range gaussianCoords {
+				for k3 := range gaussianCoords {
+					gaussToNode[i][k1*9+k2*3+k3] = lagrange(k3, point[0]) * lagrange(k2, point[1]) *
+						lagrange(k1, point[2])
+				}
+			}
+		}
+	}
+}
+
+// lagrange returns value of 1D Lagrange polynomial that is 1 at k-th Gauss coord and 0 at other ones
+func lagrange(k int, x float64) float64 {
+	v := 1.0
+	for j, coord := range gaussianCoords {
+		if j != k {
+			v *= (x - coord) / (gaussianCoords[k] - coord)
+		}
+	}
+	return v
+}
@@ -0,0 +1,152 @@
+package fem
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// ConstraintMethod selects how fixed unknowns are imposed on the linear system
+type ConstraintMethod int
+
+// Available constraint methods
+const (
+	ConstraintPenalty     ConstraintMethod = iota // Diagonal of fixed unknowns is replaced with a huge value
+	ConstraintElimination                         // Rows and columns of fixed unknowns are removed from the system
+)
+
+var constraintMethodNames = [...]string{
+	ConstraintPenalty:     "penalty",
+	ConstraintElimination: "elimination",
+}
+
+// ConstraintMethods lists all constraint methods
+var ConstraintMethods = []ConstraintMethod{ConstraintPenalty, ConstraintElimination}
+
+func (c ConstraintMethod) String() string {
+	if c < 0 || int(c) >= len(constraintMethodNames) {
+		return "unknown"
+	}
+	return constraintMethodNames[c]
+}
+
+// ParseConstraintMethod returns constraint method by its name
+func ParseConstraintMethod(name string) (ConstraintMethod, error) {
+	for _, c := range ConstraintMethods {
+		if c.String() == name {
+			return c, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown constraint method %q, expected one of %s", name,
+		strings.Join(constraintMethodNames[:], ", "))
+}
+
+// penalty is the diagonal value of fixed unknowns for ConstraintPenalty
+const penalty = 1e16
+
+// dofConstraint prescribes value of the unknown
+type dofConstraint struct {
+	dof       int
+	value     float64
+	displaced bool // Constraint comes from prescribed displacement rather than fixed side
+	side      int  // Side number of constrained side, the smallest one if there are few, -1 for displaced nodes
+}
+
+// calculateConstraints returns constraints of unknowns of nodes on fixed and displaced sides and of displaced nodes
+// ordered by unknown, node shared by sides fixed along different axes is fixed along all of them
+func (f *FEM) calculateConstraints(bc *BoundaryConditions) []dofConstraint {
+	byDOF := make(map[int]dofConstraint)
+	constrain := func(c dofConstraint) {
+		// Constraint of the same kind keeps the smallest side number, so grouping doesn't depend on map order
+		if old, ok := byDOF[c.dof]; ok && old.displaced == c.displaced && c.side >= 0 && old.side >= 0 {
+			c.side = min(c.side, old.side)
+		}
+		byDOF[c.dof] = c
+	}
+
+	for es, axes := range bc.Fixed {
+		for _, node := range f.mesh.SideNodes(es) {
+			for xyz := range 3 {
+				if axes.Has(xyz) {
+					constrain(dofConstraint{dof: 3*node + xyz, side: es.Side})
+				}
+			}
+		}
+	}
+
+	prescribe := func(node, side int, d Displacement) {
+		for xyz := range 3 {
+			if d.Axes.Has(xyz) {
+				constrain(dofConstraint{dof: 3*node + xyz, value: d.Value[xyz], displaced: true, side: side})
+			}
+		}
+	}
+
+	// Sides are visited in order, so value of node shared by sides with different displacements doesn't change
+	// between solves
+	for _, es := range slices.SortedFunc(maps.Keys(bc.Displaced), compareSides) {
+		for _, node := range f.mesh.SideNodes(es) {
+			prescribe(node, es.Side, bc.Displaced[es])
+		}
+	}
+	for node, d := range bc.DisplacedNodes {
+		prescribe(node, -1, d)
+	}
+
+	constraints := slices.Collect(maps.Values(byDOF))
+	slices.SortFunc(constraints, func(a, b dofConstraint) int { return a.dof - b.dof })
+	return constraints
+}
+
+// applyConstraints returns copy of the stiffness matrix k with constraints imposed by the method
+func applyConstraints(k *sparseMatrix, constraints []dofConstraint, method ConstraintMethod) (*sparseMatrix, error) {
+	mg := k.clone()
+	switch method {
+	case ConstraintPenalty:
+		for _, c := range constraints {
+			mg.set(c.dof, c.dof, penalty)
+		}
+	case ConstraintElimination:
+		// Row and column are zeroed keeping the diagonal, so the matrix stays symmetric and its scale is kept
+		fixed := make([]bool, mg.n)
+		for _, c := range constraints {
+			fixed[c.dof] = true
+		}
+		for i := range mg.n {
+			for p := mg.rowPtr[i]; p < mg.rowPtr[i+1]; p++ {
+				if j := mg.colIdx[p]; i != j && (fixed[i] || fixed[j]) {
+					mg.values[p] = 0
+				}
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown constraint method %d", method)
+	}
+	return mg, nil
+}
+
+// constrainedRHS returns forces f adjusted for constraints imposed on stiffness matrix k by the method
+func constrainedRHS(k *sparseMatrix, f []float64, constraints []dofConstraint, method ConstraintMethod) []float64 {
+	b := slices.Clone(f)
+	switch method {
+	case ConstraintPenalty:
+		for _, c := range constraints {
+			b[c.dof] += penalty * c.value
+		}
+	case ConstraintElimination:
+		// Known values are moved to the right side, k is symmetric, so its column is read from the row
+		for _, c := range constraints {
+			if c.value == 0 {
+				continue
+			}
+			for p := k.rowPtr[c.dof]; p < k.rowPtr[c.dof+1]; p++ {
+				b[k.colIdx[p]] -= k.values[p] * c.value
+			}
+		}
+		for _, c := range constraints {
+			b[c.dof] = k.At(c.dof, c.dof) * c.value
+		}
+	}
+	return b
+}
@@ -0,0 +1,60 @@
+package fem
+
+import (
+	"math"
+	"testing"
+)
+
+// newDisplacedProblem returns test body clamped at the bottom with top moved up and without loads
+func newDisplacedProblem(t *testing.T) (*Mesh, *BoundaryConditions) {
+	t.Helper()
+
+	mesh, bc := newTestProblem(t)
+	clear(bc.Pushed)
+	for _, es := range mesh.FaceSides(5) {
+		bc.Displaced[es] = Displacement{Axes: AxisZ, Value: [3]float64{0, 0, 0.1}}
+	}
+	return mesh, bc
+}
+
+func TestPrescribedDisplacementCG(t *testing.T) {
+	mesh, bc := newDisplacedProblem(t)
+
+	direct := New(mesh)
+	direct.Method = SolverCholesky
+	direct.Constraints = ConstraintElimination
+	expected, err := direct.Solve(testMaterial, bc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedReaction := expected.DisplacementReaction()
+
+	for _, precon := range Preconditioners {
+		t.Run(precon.String(), func(t *testing.T) {
+			f := New(mesh)
+			f.Preconditioner = precon
+			result, err := f.Solve(testMaterial, bc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if c := result.Stats().Constraints; c != ConstraintElimination {
+				t.Errorf("constraints %s, expected %s", c, ConstraintElimination)
+			}
+			if e := result.EquilibriumError(); e > 1e-6 {
+				t.Errorf("equilibrium error %g", e)
+			}
+			r := result.DisplacementReaction()
+			if math.Abs(r[2]-expectedReaction[2]) > 1e-6*math.Abs(expectedReaction[2]) {
+				t.Errorf("reaction %g, expected %g", r[2], expectedReaction[2])
+			}
+
+			u, expectedU := result.Displacements(), expected.Displacements()
+			for i := range u {
+				if math.Abs(u[i]-expectedU[i]) > 1e-6 {
+					t.Fatalf("displacement %d is %g, expected %g", i, u[i], expectedU[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package fem
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrSingularJacobian is reported when Jacobian determinant of the element is zero
+var ErrSingularJacobian = errors.New("singular Jacobian")
+
+// ConvergenceError is returned when iterative solver didn't reach required tolerance
+type ConvergenceError struct {
+	Iterations int     // Number of iterations done
+	Residual   float64 // Residual norm after the last iteration relative to forces norm
+	Err        error   // Error returned by the solver
+}
+
+func (e *ConvergenceError) Error() string {
+	return fmt.Sprintf("solver did not converge after %d iterations, residual %g: %s", e.Iterations, e.Residual, e.Err)
+}
+
+func (e *ConvergenceError) Unwrap() error {
+	return e.Err
+}
+
+// JacobianError is returned when Jacobian matrix of the element can't be inverted at Gauss point
+type JacobianError struct {
+	Element    int   // Index of the element
+	GaussPoint int   // Index of the Gauss point, 0 - 26
+	Err        error // Cause, ErrSingularJacobian
+}
+
+func (e *JacobianError) Error() string {
+	return fmt.Sprintf("invert Jacobian of element %d at Gauss point %d: %s", e.Element, e.GaussPoint, e.Err)
+}
+
+func (e *JacobianError) Unwrap() error {
+	return e.Err
+}
+
+// SideError is returned when boundary conditions reference element side that doesn't exist
+type SideError struct {
+	ElementSide ElementSide
+}
+
+func (e *SideError) Error() string {
+	return fmt.Sprintf("invalid side %d of element %d", e.ElementSide.Side, e.ElementSide.Element)
+}
@@ -0,0 +1,282 @@
+package fem
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Expression is a scalar function of position parsed from text like 2 + 0.5*z, it supports numbers, coords x, y and
+// z, constant pi, operators + - * / ^, parentheses and functions abs, sqrt, exp, log, sin, cos, tan, min and max
+type Expression struct {
+	text string
+	eval func(x [3]float64) float64
+}
+
+// ParseExpression parses expression of coords x, y and z
+func ParseExpression(text string) (*Expression, error) {
+	p := &expressionParser{text: text}
+	p.next()
+	eval, err := p.sum()
+	if err == nil && p.token != "" {
+		err = p.errorf("unexpected %q", p.token)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Expression{text: strings.TrimSpace(text), eval: eval}, nil
+}
+
+// Eval returns value of the expression at the point
+func (e *Expression) Eval(x [3]float64) float64 {
+	return e.eval(x)
+}
+
+func (e *Expression) String() string {
+	return e.text
+}
+
+// MarshalText encodes expression as its text
+func (e *Expression) MarshalText() ([]byte, error) {
+	return []byte(e.text), nil
+}
+
+// UnmarshalText parses expression from text
+func (e *Expression) UnmarshalText(text []byte) error {
+	parsed, err := ParseExpression(string(text))
+	if err != nil {
+		return err
+	}
+	*e = *parsed
+	return nil
+}
+
+type expressionFunc = func(x [3]float64) float64
+
+// expressionFuncs lists functions with their number of arguments
+var expressionFuncs = map[string]struct {
+	args int
+	eval func(args []float64) float64
+}{
+	"abs":  {1, func(a []float64) float64 { return math.Abs(a[0]) }},
+	"sqrt": {1, func(a []float64) float64 { return math.Sqrt(a[0]) }},
+	"exp":  {1, func(a []float64) float64 { return math.Exp(a[0]) }},
+	"log":  {1, func(a []float64) float64 { return math.Log(a[0]) }},
+	"sin":  {1, func(a []float64) float64 { return math.Sin(a[0]) }},
+	"cos":  {1, func(a []float64) float64 { return math.Cos(a[0]) }},
+	"tan":  {1, func(a []float64) float64 { return math.Tan(a[0]) }},
+	"min":  {2, func(a []float64) float64 { return math.Min(a[0], a[1]) }},
+	"max":  {2, func(a []float64) float64 { return math.Max(a[0], a[1]) }},
+}
+
+// expressionParser is a recursive descent parser, every rule reads tokens starting from the current one
+type expressionParser struct {
+	text  string
+	pos   int    // Position after the current token
+	start int    // Position of the current token
+	token string // Current token, empty at the end of text
+}
+
+func (p *expressionParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("parse expression %q at %d: %s", p.text, p.start, fmt.Sprintf(format, args...))
+}
+
+// next reads the next token, a number, a name or a single character
+func (p *expressionParser) next() {
+	for p.pos < len(p.text) && unicode.IsSpace(rune(p.text[p.pos])) {
+		p.pos++
+	}
+	p.start = p.pos
+	if p.pos == len(p.text) {
+		p.token = ""
+		return
+	}
+
+	c := p.text[p.pos]
+	switch {
+	case isDigit(c) || c == '.':
+		for p.pos < len(p.text) && (isDigit(p.text[p.pos]) || p.text[p.pos] == '.') {
+			p.pos++
+		}
+		// Exponent is part of the number only if it has digits
+		if p.pos < len(p.text) && (p.text[p.pos] == 'e' || p.text[p.pos] == 'E') {
+			end := p.pos + 1
+			if end < len(p.text) && (p.text[end] == '+' || p.text[end] == '-') {
+				end++
+			}
+			if end < len(p.text) && isDigit(p.text[end]) {
+				for end < len(p.text) && isDigit(p.text[end]) {
+					end++
+				}
+				p.pos = end
+			}
+		}
+	case unicode.IsLetter(rune(c)):
+		for p.pos < len(p.text) && (unicode.IsLetter(rune(p.text[p.pos])) || isDigit(p.text[p.pos])) {
+			p.pos++
+		}
+	default:
+		p.pos++
+	}
+	p.token = p.text[p.start:p.pos]
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// sum = product {("+" | "-") product}
+func (p *expressionParser) sum() (expressionFunc, error) {
+	left, err := p.product()
+	if err != nil {
+		return nil, err
+	}
+	for p.token == "+" || p.token == "-" {
+		op := p.token
+		p.next()
+		right, err := p.product()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		if op == "+" {
+			left = func(x [3]float64) float64 { return l(x) + right(x) }
+		} else {
+			left = func(x [3]float64) float64 { return l(x) - right(x) }
+		}
+	}
+	return left, nil
+}
+
+// product = unary {("*" | "/") unary}
+func (p *expressionParser) product() (expressionFunc, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.token == "*" || p.token == "/" {
+		op := p.token
+		p.next()
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		if op == "*" {
+			left = func(x [3]float64) float64 { return l(x) * right(x) }
+		} else {
+			left = func(x [3]float64) float64 { return l(x) / right(x) }
+		}
+	}
+	return left, nil
+}
+
+// unary = ("-" | "+") unary | power
+func (p *expressionParser) unary() (expressionFunc, error) {
+	switch p.token {
+	case "-":
+		p.next()
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return func(x [3]float64) float64 { return -operand(x) }, nil
+	case "+":
+		p.next()
+		return p.unary()
+	}
+	return p.power()
+}
+
+// power = primary ["^" unary], so power is right associative and binds tighter than unary minus on its left
+func (p *expressionParser) power() (expressionFunc, error) {
+	base, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	if p.token != "^" {
+		return base, nil
+	}
+	p.next()
+	exponent, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	return func(x [3]float64) float64 { return math.Pow(base(x), exponent(x)) }, nil
+}
+
+// primary = number | coord | "pi" | name "(" sum {"," sum} ")" | "(" sum ")"
+func (p *expressionParser) primary() (expressionFunc, error) {
+	token := p.token
+	switch {
+	case token == "":
+		return nil, p.errorf("unexpected end")
+	case token == "(":
+		p.next()
+		inner, err := p.sum()
+		if err != nil {
+			return nil, err
+		}
+		if p.token != ")" {
+			return nil, p.errorf("expected \")\"")
+		}
+		p.next()
+		return inner, nil
+	case isDigit(token[0]) || token[0] == '.':
+		v, err := strconv.ParseFloat(token, 64)
+		if err != nil {
+			return nil, p.errorf("invalid number %q", token)
+		}
+		p.next()
+		return func([3]float64) float64 { return v }, nil
+	case token == "x" || token == "y" || token == "z":
+		axis := int(token[0] - 'x')
+		p.next()
+		return func(x [3]float64) float64 { return x[axis] }, nil
+	case token == "pi":
+		p.next()
+		return func([3]float64) float64 { return math.Pi }, nil
+	case !unicode.IsLetter(rune(token[0])):
+		return nil, p.errorf("unexpected %q", token)
+	}
+
+	fn, ok := expressionFuncs[token]
+	if !ok {
+		return nil, p.errorf("unknown name %q", token)
+	}
+	p.next()
+	if p.token != "(" {
+		return nil, p.errorf("expected \"(\" after %s", token)
+	}
+	p.next()
+
+	var args []expressionFunc
+	for {
+		arg, err := p.sum()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		if p.token != "," {
+			break
+		}
+		p.next()
+	}
+	if p.token != ")" {
+		return nil, p.errorf("expected \")\"")
+	}
+	if len(args) != fn.args {
+		return nil, p.errorf("%s expects %d arguments, got %d", token, fn.args, len(args))
+	}
+	p.next()
+
+	return func(x [3]float64) float64 {
+		values := make([]float64, len(args))
+		for i, arg := range args {
+			values[i] = arg(x)
+		}
+		return fn.eval(values)
+	}, nil
+}
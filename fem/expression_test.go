@@ -0,0 +1,80 @@
+package fem
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestParseExpression(t *testing.T) {
+	x := [3]float64{1, 2, 4}
+
+	tests := []struct {
+		text     string
+		expected float64
+	}{
+		{"2 + 0.5*z", 4},
+		{"1 + 2*3", 7},
+		{"(1 + 2)*3", 9},
+		{"8 / 4 / 2", 1},
+		{"2 - 3 - 4", -5},
+		{"-2^2", -4},
+		{"2^3^2", 512},
+		{"2^-1", 0.5},
+		{"-x + +y", 1},
+		{"1e-3", 0.001},
+		{"2.5E+2", 250},
+		{".5", 0.5},
+		{"pi", math.Pi},
+		{"cos(pi*x)", -1},
+		{"sqrt(z) * abs(-y)", 4},
+		{"exp(0) + log(1)", 1},
+		{"min(x, y) + max(y, z)", 5},
+		{"max(min(x, z), 3 - y)", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			e, err := ParseExpression(tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v := e.Eval(x); math.Abs(v-tt.expected) > 1e-12 {
+				t.Errorf("%s = %g, expected %g", tt.text, v, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseExpressionErrors(t *testing.T) {
+	tests := []struct {
+		text string
+		err  string
+	}{
+		{"", "unexpected end"},
+		{"2 +", "unexpected end"},
+		{"2 + *z", `unexpected "*"`},
+		{"min(1)", "min expects 2 arguments, got 1"},
+		{"sin(1, 2)", "sin expects 1 arguments, got 2"},
+		{"w + 1", `unknown name "w"`},
+		{"sinx", `unknown name "sinx"`},
+		{"sin x", `expected "(" after sin`},
+		{"(1 + 2", `expected ")"`},
+		{"max(1, 2", `expected ")"`},
+		{"1 + 2)", `unexpected ")"`},
+		{"2 x", `unexpected "x"`},
+		{"1.2.3", `invalid number "1.2.3"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			_, err := ParseExpression(tt.text)
+			if err == nil {
+				t.Fatalf("expected error containing %q", tt.err)
+			}
+			if !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("error %q doesn't contain %q", err, tt.err)
+			}
+		})
+	}
+}
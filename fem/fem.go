@@ -0,0 +1,722 @@
+package fem
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"gonum.org/v1/exp/linsolve"
+	"gonum.org/v1/gonum/mat"
+)
+
+// FEM solves deformation problem of the mesh and keeps intermediate results of the last solve
+type FEM struct {
+	mesh *Mesh
+
+	zu map[ElementSide]Axes // Fixed points, index of the element and side with fixed axes
+	zp map[ElementSide]bool // Pushed points, index of the element and side
+
+	k  *sparseMatrix // Stiffness matrix without constraints, npq * 3 (x, y, z) * npq * 3 (x, y, z)
+	mg *sparseMatrix // Stiffness matrix with constraints imposed, npq * 3 (x, y, z) * npq * 3 (x, y, z)
+
+	constraints []dofConstraint // Fixed and displaced unknowns
+
+	fe [][60]float64 // Forces for elements, npq * 60
+	f  []float64     // Forces, npq * 3 (x, y, z)
+
+	u []float64 // Displacements, npq * 3 (x, y, z)
+
+	stiffness stiffnessKey // Materials, fixed sides and constraint method of assembled stiffness matrix
+	factor    *ldlFactor   // Factorization of the stiffness matrix, nil until needed
+
+	Workers  int             // Number of goroutines used for element computations, GOMAXPROCS if not positive
+	Progress chan<- Progress // Receives progress of the solve if not nil, updates are dropped while not ready
+
+	Method         SolverMethod     // Method used to solve linear system
+	Constraints    ConstraintMethod // Method used to impose fixed sides
+	Preconditioner Preconditioner   // Preconditioner of the conjugate gradient solver
+	Tolerance      float64          // Relative residual norm at which solver stops, defaultTolerance if not positive
+	MaxIterations  int              // Limit of solver iterations, 4 * number of unknowns if not positive
+}
+
+// stiffnessKey identifies inputs that stiffness matrix depends on, values of constrained unknowns only change forces
+type stiffnessKey struct {
+	materials   []Material // Material of each element
+	dofs        []int      // Constrained unknowns
+	constraints ConstraintMethod
+}
+
+func newStiffnessKey(materials *Materials, constraints []dofConstraint, method ConstraintMethod) stiffnessKey {
+	key := stiffnessKey{
+		materials:   make([]Material, len(materials.Elements)),
+		dofs:        make([]int, len(constraints)),
+		constraints: method,
+	}
+	for i := range materials.Elements {
+		key.materials[i] = materials.element(i)
+	}
+	for i, c := range constraints {
+		key.dofs[i] = c.dof
+	}
+	return key
+}
+
+func (k stiffnessKey) equal(other stiffnessKey) bool {
+	return slices.Equal(k.materials, other.materials) && slices.Equal(k.dofs, other.dofs) &&
+		k.constraints == other.constraints
+}
+
+// defaultTolerance is relative residual norm at which iterative solver stops
+const defaultTolerance = 1e-8
+
+// New creates solver for the mesh
+func New(mesh *Mesh) *FEM {
+	return &FEM{mesh: mesh}
+}
+
+// Solve computes deformation of the mesh made of material under boundary conditions
+func Solve(mesh *Mesh, material Material, bc *BoundaryConditions) (*Result, error) {
+	return New(mesh).Solve(material, bc)
+}
+
+// Solve computes deformation of the mesh made of material under boundary conditions
+func (f *FEM) Solve(material Material, bc *BoundaryConditions) (*Result, error) {
+	return f.SolveContext(context.Background(), material, bc)
+}
+
+// SolveContext computes deformation of the mesh made of material under boundary conditions, solve is stopped with
+// context error when ctx is done
+func (f *FEM) SolveContext(ctx context.Context, material Material, bc *BoundaryConditions) (*Result, error) {
+	if err := material.Validate(); err != nil {
+		return nil, err
+	}
+	return f.SolveMaterials(ctx, UniformMaterials(material, len(f.mesh.elements)), bc)
+}
+
+// SolveMaterials computes deformation of the mesh with elements made of different materials under boundary
+// conditions, solve is stopped with context error when ctx is done
+func (f *FEM) SolveMaterials(ctx context.Context, materials *Materials, bc *BoundaryConditions) (*Result, error) {
+	if err := materials.Validate(len(f.mesh.elements)); err != nil {
+		return nil, err
+	}
+	if err := bc.validate(f.mesh); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	defer func() { slog.Info("FEM", "total-time", time.Since(start)) }()
+
+	f.zu = bc.Fixed
+	f.zp = bc.Pushed
+	p := bc.Pressure
+
+	// Stiffness matrix and its factorization are kept while materials and constrained unknowns stay the same
+	f.constraints = f.calculateConstraints(bc)
+	method := f.constraintMethod()
+	key := newStiffnessKey(materials, f.constraints, method)
+	if f.mg == nil || !f.stiffness.equal(key) {
+		f.k, f.mg, f.factor = nil, nil, nil
+		if err := f.assembleStiffness(ctx, materials); err != nil {
+			return nil, err
+		}
+
+		mg, err := applyConstraints(f.k, f.constraints, method)
+		if err != nil {
+			return nil, err
+		}
+		f.mg = mg
+		f.stiffness = key
+	}
+
+	f.report(Progress{Stage: StageAssembly})
+	f.fe = make([][60]float64, len(f.mesh.nt))
+	addLoad := func(es ElementSide, load sideLoad) error {
+		fe, err := f.calculateFE(es, f.mesh.Side(es), load)
+		if err != nil {
+			return err
+		}
+		for i := range fe {
+			f.fe[es.Element][i] += fe[i]
+		}
+		return nil
+	}
+	pressure := pressureLoad(p)
+	if bc.PressureField != nil {
+		pressure = pressureFieldLoad(bc.PressureField)
+	}
+	for es, push := range f.zp {
+		if push {
+			if err := addLoad(es, pressure); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for es, t := range bc.Tractions {
+		if err := addLoad(es, t.load(es.Side)); err != nil {
+			return nil, err
+		}
+	}
+	if !bc.BodyForce.zero() {
+		for i := range f.fe {
+			fe := f.calculateBodyFE(i, bc.BodyForce)
+			for j := range fe {
+				f.fe[i][j] += fe[j]
+			}
+		}
+	}
+	f.f = f.calculateF()
+	for node, force := range bc.NodalLoads {
+		for xyz := range 3 {
+			f.f[3*node+xyz] += force[xyz]
+		}
+	}
+	b := constrainedRHS(f.k, f.f, f.constraints, method)
+	f.report(Progress{Stage: StageAssembly, Done: 1, Total: 1})
+
+	var stats SolveStats
+	var err error
+	switch f.Method {
+	case SolverCG:
+		f.u, stats, err = f.solveCG(ctx, b)
+	case SolverCholesky:
+		f.u, stats, err = f.solveCholesky(ctx, b)
+	default:
+		err = fmt.Errorf("unknown solver method %d", f.Method)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if method == ConstraintElimination {
+		// Solver gives constrained values only approximately, they are known exactly
+		for _, c := range f.constraints {
+			f.u[c.dof] = c.value
+		}
+	}
+	stats.Constraints = method
+	slog.Info("FEM", "method", stats.Method, "preconditioner", stats.Preconditioner, "iterations", stats.Iterations,
+		"residual", stats.Residual, "solve-time", stats.Duration)
+
+	f.report(Progress{Stage: StageStress})
+	strain, stress, err := f.calculateStrainStress(materials)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{
+		mesh:        f.mesh,
+		u:           f.u,
+		strain:      strain,
+		stress:      stress,
+		nodalStress: f.calculateNodalStress(stress),
+		forces:      f.f,
+		reactions:   f.calculateReactions(),
+		constraints: f.constraints,
+		tolerance:   f.tolerance(),
+		stats:       stats,
+	}
+	if !result.Balanced() {
+		slog.Warn("FEM result is not balanced", "equilibrium-error", result.EquilibriumError())
+	}
+	return result, nil
+}
+
+// tolerance returns relative residual norm at which solver stops
+func (f *FEM) tolerance() float64 {
+	if f.Tolerance <= 0 {
+		return defaultTolerance
+	}
+	return f.Tolerance
+}
+
+// constraintMethod returns method used to impose constraints of the solve. Penalty terms of prescribed non-zero
+// displacements dominate the forces norm, so iterative solver would stop before free unknowns moved and their
+// residual would be lost in rounding, elimination is used for it instead
+func (f *FEM) constraintMethod() ConstraintMethod {
+	if f.Constraints != ConstraintPenalty || f.Method != SolverCG {
+		return f.Constraints
+	}
+	for _, c := range f.constraints {
+		if c.value != 0 {
+			return ConstraintElimination
+		}
+	}
+	return f.Constraints
+}
+
+// calculateReactions returns forces that constraints apply to constrained unknowns, K * u - f with stiffness matrix
+// without constraints, other unknowns are zero
+func (f *FEM) calculateReactions() []float64 {
+	reactions := make([]float64, len(f.u))
+	for _, c := range f.constraints {
+		r := -f.f[c.dof]
+		for p := f.k.rowPtr[c.dof]; p < f.k.rowPtr[c.dof+1]; p++ {
+			r += f.k.values[p] * f.u[f.k.colIdx[p]]
+		}
+		reactions[c.dof] = r
+	}
+	return reactions
+}
+
+// stiffnessBatch is number of elements whose stiffness matrices are computed before they are added to global
+// stiffness matrix, so matrices of all elements are never kept at once
+const stiffnessBatch = 256
+
+// assembleStiffness computes stiffness matrices of elements in batches and adds them to global stiffness matrix
+func (f *FEM) assembleStiffness(ctx context.Context, materials *Materials) error {
+	elements := len(f.mesh.elements)
+	k := newStiffnessPattern(len(f.mesh.akt), f.mesh.nt)
+
+	mge := make([][60][60]float64, min(stiffnessBatch, elements))
+	errs := make([]error, len(mge))
+	var done atomic.Int64
+	for start := 0; start < elements; start += stiffnessBatch {
+		n := min(stiffnessBatch, elements-start)
+		parallelFor(n, f.Workers, func(i int) {
+			if ctx.Err() != nil {
+				return
+			}
+
+			element := start + i
+			dfixyz, djDet, err := f.elementDerivatives(element)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			mge[i] = f.createMGE(dfixyz, djDet, materials.element(element).elasticity())
+			f.report(Progress{Stage: StageStiffness, Done: int(done.Add(1)), Total: elements})
+		})
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		for _, err := range errs[:n] {
+			if err != nil {
+				return err
+			}
+		}
+
+		// Matrices are added in order of elements, so sums don't depend on workers count
+		for i := range n {
+			addElementStiffness(k, f.mesh.nt[start+i], &mge[i])
+		}
+	}
+
+	f.k = k
+	return nil
+}
+
+// elementDerivatives returns derivatives of approximation functions in global space and Jacobian determinants at
+// Gauss points of the element
+func (f *FEM) elementDerivatives(element int) ([27][20][3]float64, [27]float64, error) {
+	dj := f.createDJ(f.mesh.elements[element])
+	djDet := jacobianDeterminants(dj)
+	dfixyz, err := f.createDFIXYZ(element, dj, djDet)
+	return dfixyz, djDet, err
+}
+
+// jacobianDeterminants returns determinants of Jacobian matrices at Gauss points
+func jacobianDeterminants(dj [27][3][3]float64) [27]float64 {
+	var ds [27]float64
+	for j, d := range dj {
+		ds[j] = d[0][0]*d[1][1]*d[2][2] +
+			d[0][1]*d[1][2]*d[2][0] +
+			d[0][2]*d[1][0]*d[2][1] -
+			d[0][2]*d[1][1]*d[2][0] - d[0][0]*
+			d[1][2]*d[2][1] -
+			d[0][1]*d[1][0]*d[2][2]
+	}
+	return ds
+}
+
+// solveCG solves assembled system with preconditioned conjugate gradient method
+func (f *FEM) solveCG(ctx context.Context, rhs []float64) ([]float64, SolveStats, error) {
+	b := mat.NewVecDense(len(rhs), rhs)
+	bNorm := mat.Norm(b, 2)
+	if bNorm == 0 {
+		bNorm = 1
+	}
+
+	solveStart := time.Now()
+	precon, err := newPreconSolve(f.Preconditioner, f.mg)
+	if err != nil {
+		return nil, SolveStats{}, err
+	}
+
+	method := &progressMethod{
+		Method:        &linsolve.CG{},
+		ctx:           ctx,
+		f:             f,
+		bNorm:         bNorm,
+		tolerance:     f.tolerance(),
+		maxIterations: f.MaxIterations,
+	}
+	if method.maxIterations <= 0 {
+		method.maxIterations = 4 * len(rhs)
+	}
+
+	uVec, err := linsolve.Iterative(f.mg, b, method, &linsolve.Settings{
+		Tolerance:     method.tolerance,
+		MaxIterations: method.maxIterations,
+		PreconSolve:   precon,
+	})
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, SolveStats{}, ctxErr
+	}
+	if err != nil {
+		return nil, SolveStats{}, &ConvergenceError{
+			Iterations: uVec.Stats.Iterations,
+			Residual:   uVec.ResidualNorm / bNorm,
+			Err:        err,
+		}
+	}
+
+	return uVec.X.RawVector().Data, SolveStats{
+		Method:         SolverCG,
+		Preconditioner: f.Preconditioner,
+		Iterations:     uVec.Stats.Iterations,
+		MulVec:         uVec.Stats.MulVec,
+		PreconSolve:    uVec.Stats.PreconSolve,
+		Residual:       uVec.ResidualNorm / bNorm,
+		Duration:       time.Since(solveStart),
+	}, nil
+}
+
+// solveCholesky solves assembled system with LDLᵀ factorization, computing it only if there is no cached one
+func (f *FEM) solveCholesky(ctx context.Context, rhs []float64) ([]float64, SolveStats, error) {
+	solveStart := time.Now()
+
+	factorized := false
+	if f.factor == nil {
+		factor, err := newLDL(ctx, f.mg, nestedDissection(f.mg, f.mesh.akt), func(done, total int) {
+			f.report(Progress{Stage: StageFactorization, Done: done, Total: total})
+		})
+		if err != nil {
+			return nil, SolveStats{}, err
+		}
+		f.factor = factor
+		factorized = true
+	}
+	u := f.factor.solve(rhs)
+
+	// Residual is computed directly as there is no iterative estimate of it
+	b := mat.NewVecDense(len(rhs), rhs)
+	r := mat.NewVecDense(len(rhs), nil)
+	f.mg.MulVecTo(r, false, mat.NewVecDense(len(u), u))
+	r.SubVec(r, b)
+	bNorm := mat.Norm(b, 2)
+	if bNorm == 0 {
+		bNorm = 1
+	}
+
+	return u, SolveStats{
+		Method:         SolverCholesky,
+		MulVec:         1,
+		Residual:       mat.Norm(r, 2) / bNorm,
+		Duration:       time.Since(solveStart),
+		Factorized:     factorized,
+		FactorNonzeros: f.factor.nonzeros(),
+	}, nil
+}
+
+func (f *FEM) createDJ(cube [20][3]float64) [27][3][3]float64 {
+	const eps = 1e-10
+
+	var dj [27][3][3]float64
+	for i := range 3 * 3 * 3 {
+		var sumXA, sumXB, sumXG float64
+		var sumYA, sumYB, sumYG float64
+		var sumZA, sumZB, sumZG float64
+
+		for j, point := range cube {
+			sumXA += point[0] * dfiabg[i][j][0]
+			sumXB += point[0] * dfiabg[i][j][1]
+			sumXG += point[0] * dfiabg[i][j][2]
+
+			sumYA += point[1] * dfiabg[i][j][0]
+			sumYB += point[1] * dfiabg[i][j][1]
+			sumYG += point[1] * dfiabg[i][j][2]
+
+			sumZA += point[2] * dfiabg[i][j][0]
+			sumZB += point[2] * dfiabg[i][j][1]
+			sumZG += point[2] * dfiabg[i][j][2]
+		}
+
+		dj[i] = [3][3]float64{
+			{sumXA, sumYA, sumZA},
+			{sumXB, sumYB, sumZB},
+			{sumXG, sumYG, sumZG},
+		}
+
+		// Added rounding for values close to -1, 0, 1
+		for j := range dj[i] {
+			for k := range dj[i][j] {
+				v := dj[i][j][k]
+				if math.Abs(v) < eps {
+					v = 0
+				} else if math.Abs(v-1) < eps {
+					v = 1
+				} else if math.Abs(v+1) < eps {
+					v = -1
+				}
+				dj[i][j][k] = v
+			}
+		}
+	}
+	return dj
+}
+
+// createDFIXYZ solves dj * dfixyz = dfiabg at each Gauss point with inverse of Jacobian matrix
+func (f *FEM) createDFIXYZ(element int, dj [27][3][3]float64, djDet [27]float64) ([27][20][3]float64, error) {
+	var dfixyz [27][20][3]float64
+	for i, d := range dj {
+		if djDet[i] == 0 {
+			return dfixyz, &JacobianError{Element: element, GaussPoint: i, Err: ErrSingularJacobian}
+		}
+
+		// Inverse is transposed matrix of cofactors divided by determinant
+		var inv [3][3]float64
+		for r := range 3 {
+			for c := range 3 {
+				r1, r2 := (c+1)%3, (c+2)%3
+				c1, c2 := (r+1)%3, (r+2)%3
+				inv[r][c] = (d[r1][c1]*d[r2][c2] - d[r1][c2]*d[r2][c1]) / djDet[i]
+			}
+		}
+
+		for j, points := range dfiabg[i] {
+			for xyz := range 3 {
+				dfixyz[i][j][xyz] = inv[xyz][0]*points[0] + inv[xyz][1]*points[1] + inv[xyz][2]*points[2]
+			}
+		}
+	}
+	return dfixyz, nil
+}
+
+// createMGE integrates Bᵀ * D * B over the element, where columns of B are strains of unit displacement of each node
+// along each axis and D is constitutive matrix
+func (f *FEM) createMGE(dfixyz [27][20][3]float64, djDet [27]float64, d [6][6]float64) [60][60]float64 {
+	var mge [60][60]float64
+
+	index := 0
+	for _, m := range gaussianConst {
+		for _, n := range gaussianConst {
+			for _, k := range gaussianConst {
+				weight := m * n * k * math.Abs(djDet[index])
+				dfi := dfixyz[index]
+
+				// Stresses of unit displacements of nodes, D * B
+				var db [60]Tensor
+				for j := range 20 {
+					for xyz, strain := range nodeStrains(dfi[j]) {
+						db[20*xyz+j] = stressOf(d, strain)
+					}
+				}
+
+				// Column of B has only three nonzero strain components, matrix is symmetric, so only upper half is
+				// computed
+				for row := range 60 {
+					xyzI, i := row/20, row%20
+					components, derivatives := strainComponents[xyzI], strainDerivatives[xyzI]
+					g0, g1, g2 := dfi[i][derivatives[0]], dfi[i][derivatives[1]], dfi[i][derivatives[2]]
+					for col := row; col < 60; col++ {
+						stress := &db[col]
+						mge[row][col] += weight *
+							(g0*stress[components[0]] + g1*stress[components[1]] + g2*stress[components[2]])
+					}
+				}
+				index++
+			}
+		}
+	}
+
+	for row := range 60 {
+		for col := range row {
+			mge[row][col] = mge[col][row]
+		}
+	}
+	return mge
+}
+
+// strainComponents are Voigt components of strain of unit displacement along x, y and z, strainDerivatives are
+// derivatives of approximation function they are equal to
+var (
+	strainComponents  = [3][3]int{{0, 3, 5}, {1, 3, 4}, {2, 4, 5}}
+	strainDerivatives = [3][3]int{{0, 1, 2}, {1, 0, 2}, {2, 1, 0}}
+)
+
+// nodeStrains returns strains of unit displacement of the node along x, y and z, dfi is derivative of its
+// approximation function in global space
+func nodeStrains(dfi [3]float64) [3]Tensor {
+	var strains [3]Tensor
+	for xyz := range 3 {
+		for t, component := range strainComponents[xyz] {
+			strains[xyz][component] = dfi[strainDerivatives[xyz][t]]
+		}
+	}
+	return strains
+}
+
+// sideLoad returns force acting at point x of the side per unit of local side area, n is outward normal of the side
+// scaled by ratio of global and local areas
+type sideLoad func(x, n [3]float64) [3]float64
+
+// pressureLoad returns load of pressure p acting against outward normal
+func pressureLoad(p float64) sideLoad {
+	return func(_, n [3]float64) [3]float64 {
+		return [3]float64{-p * n[0], -p * n[1], -p * n[2]}
+	}
+}
+
+// pressureFieldLoad returns load of pressure given by expression of position acting against outward normal
+func pressureFieldLoad(e *Expression) sideLoad {
+	return func(x, n [3]float64) [3]float64 {
+		p := e.Eval(x)
+		return [3]float64{-p * n[0], -p * n[1], -p * n[2]}
+	}
+}
+
+func (f *FEM) calculateFE(es ElementSide, zp [8][3]float64, load sideLoad) ([60]float64, error) {
+	if !es.valid(len(f.mesh.elements)) {
+		return [60]float64{}, &SideError{ElementSide: es}
+	}
+
+	dXYZdNT := f.dXYZdNT(zp)
+	var fe1, fe2, fe3 [8]float64
+
+	index := 0
+	for _, m := range gaussianConst {
+		for _, n := range gaussianConst {
+			dXYZdNTi := dXYZdNT[index]
+			normal := [3]float64{
+				dXYZdNTi[1][0]*dXYZdNTi[2][1] - dXYZdNTi[2][0]*dXYZdNTi[1][1],
+				dXYZdNTi[2][0]*dXYZdNTi[0][1] - dXYZdNTi[0][0]*dXYZdNTi[2][1],
+				dXYZdNTi[0][0]*dXYZdNTi[1][1] - dXYZdNTi[1][0]*dXYZdNTi[0][1],
+			}
+
+			var point [3]float64
+			for i, p := range zp {
+				for xyz := range 3 {
+					point[xyz] += dpsiteXYZdeNT[index][i] * p[xyz]
+				}
+			}
+
+			force := load(point, normal)
+			for i := range 8 {
+				dpsiteXYZdeNTi := dpsiteXYZdeNT[index][i]
+				fe1[i] += m * n * force[0] * dpsiteXYZdeNTi
+				fe2[i] += m * n * force[1] * dpsiteXYZdeNTi
+				fe3[i] += m * n * force[2] * dpsiteXYZdeNTi
+			}
+			index++
+		}
+	}
+
+	// Side points are ordered the same way as in Side, so their element local indexes are known
+	var fe [60]float64
+	for i, j := range cubeSideIndexes(f.mesh.elements[es.Element], es.Side) {
+		fe[j] = fe1[i]
+		fe[20+j] = fe2[i]
+		fe[40+j] = fe3[i]
+	}
+	return fe, nil
+}
+
+// calculateBodyFE integrates body force over the element
+func (f *FEM) calculateBodyFE(element int, body BodyForce) [60]float64 {
+	cube := f.mesh.elements[element]
+	djDet := jacobianDeterminants(f.createDJ(cube))
+
+	var fe [60]float64
+	index := 0
+	for _, m := range gaussianConst {
+		for _, n := range gaussianConst {
+			for _, k := range gaussianConst {
+				fi := fiabg[index]
+
+				var point [3]float64
+				for i, p := range cube {
+					for xyz := range 3 {
+						point[xyz] += fi[i] * p[xyz]
+					}
+				}
+
+				force := body.at(point)
+				weight := m * n * k * math.Abs(djDet[index])
+				for i := range 20 {
+					fe[i] += weight * force[0] * fi[i]
+					fe[20+i] += weight * force[1] * fi[i]
+					fe[40+i] += weight * force[2] * fi[i]
+				}
+				index++
+			}
+		}
+	}
+	return fe
+}
+
+func (f *FEM) dXYZdNT(points [8][3]float64) [3 * 3][3][2]float64 {
+	var dXYZdNT [9][3][2]float64
+	for i := range 3 * 3 {
+		var sumXEta, sumYEta, sumZEta float64
+		var sumXTau, sumYTau, sumZTau float64
+
+		for j, point := range points {
+			sumXEta += point[0] * dpsite[i][j][0]
+			sumYEta += point[1] * dpsite[i][j][0]
+			sumZEta += point[2] * dpsite[i][j][0]
+			sumXTau += point[0] * dpsite[i][j][1]
+			sumYTau += point[1] * dpsite[i][j][1]
+			sumZTau += point[2] * dpsite[i][j][1]
+		}
+
+		dXYZdNT[i] = [3][2]float64{
+			{sumXEta, sumXTau},
+			{sumYEta, sumYTau},
+			{sumZEta, sumZTau},
+		}
+	}
+	return dXYZdNT
+}
+
+// addElementStiffness adds stiffness matrix of the element with nodes nt to global stiffness matrix
+func addElementStiffness(mg *sparseMatrix, nt [20]int, mge *[60][60]float64) {
+	for j := range 20 {
+		for i := range 20 {
+			// Columns of one node are stored next to each other, so only index of x column is looked up
+			for xyzCoordJ := range 3 {
+				mgJ := 3*nt[j] + xyzCoordJ
+				mgI := mg.index(mgJ, 3*nt[i])
+				for xyzCoordI := range 3 {
+					mg.values[mgI+xyzCoordI] += mge[20*xyzCoordJ+j][20*xyzCoordI+i]
+				}
+			}
+		}
+	}
+}
+
+func (f *FEM) calculateF() []float64 {
+	fr := make([]float64, 3*len(f.mesh.akt))
+
+	for j, fe := range f.fe {
+		for i := range 60 {
+			var iForNT, xyzCoordI int
+			if i < 20 {
+				iForNT = i
+				xyzCoordI = 0
+			} else if i < 40 {
+				iForNT = i - 20
+				xyzCoordI = 1
+			} else {
+				iForNT = i - 40
+				xyzCoordI = 2
+			}
+
+			fI := 3*f.mesh.nt[j][iForNT] + xyzCoordI
+			fr[fI] += fe[i]
+		}
+	}
+
+	return fr
+}
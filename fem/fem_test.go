@@ -0,0 +1,49 @@
+package fem
+
+import (
+	"errors"
+	"testing"
+)
+
+// newTestProblem returns small body fixed at the bottom and pushed at the top
+func newTestProblem(t *testing.T) (*Mesh, *BoundaryConditions) {
+	t.Helper()
+
+	mesh, err := NewMesh([3]float64{2, 3, 2}, [3]int{3, 3, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bc := NewBoundaryConditions()
+	for _, es := range mesh.FaceSides(4) {
+		bc.Fixed[es] = AxesAll
+	}
+	for _, es := range mesh.FaceSides(5) {
+		bc.Pushed[es] = true
+	}
+	bc.Pressure = 2
+	return mesh, bc
+}
+
+var testMaterial = Material{YoungsModulus: 4, PoissonRatio: 0.3}
+
+func TestConvergenceErrorRelativeResidual(t *testing.T) {
+	mesh, bc := newTestProblem(t)
+	bc.Pressure = 1e6
+
+	f := New(mesh)
+	f.MaxIterations = 5
+	_, err := f.Solve(testMaterial, bc)
+
+	var convergenceErr *ConvergenceError
+	if !errors.As(err, &convergenceErr) {
+		t.Fatalf("expected convergence error, got %v", err)
+	}
+	if convergenceErr.Iterations != 5 {
+		t.Errorf("iterations %d, expected 5", convergenceErr.Iterations)
+	}
+	// Relative residual doesn't depend on load scale
+	if r := convergenceErr.Residual; !(r > 0 && r < 1) {
+		t.Errorf("residual %g is not relative to forces norm", r)
+	}
+}
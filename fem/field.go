@@ -0,0 +1,72 @@
+package fem
+
+import "math"
+
+// Field is a scalar result field defined at nodes
+type Field int
+
+const (
+	FieldDisplacement Field = iota // Displacement magnitude
+	FieldDisplacementX
+	FieldDisplacementY
+	FieldDisplacementZ
+	FieldVonMises
+	FieldPrincipal1 // Max principal stress
+	FieldPrincipal2 // Mid principal stress
+	FieldPrincipal3 // Min principal stress
+)
+
+// Fields lists all scalar fields in display order
+var Fields = []Field{
+	FieldDisplacement,
+	FieldDisplacementX,
+	FieldDisplacementY,
+	FieldDisplacementZ,
+	FieldVonMises,
+	FieldPrincipal1,
+	FieldPrincipal2,
+	FieldPrincipal3,
+}
+
+func (f Field) String() string {
+	switch f {
+	case FieldDisplacement:
+		return "Displacement"
+	case FieldDisplacementX:
+		return "Displacement X"
+	case FieldDisplacementY:
+		return "Displacement Y"
+	case FieldDisplacementZ:
+		return "Displacement Z"
+	case FieldVonMises:
+		return "Von Mises stress"
+	case FieldPrincipal1:
+		return "Principal stress 1"
+	case FieldPrincipal2:
+		return "Principal stress 2"
+	case FieldPrincipal3:
+		return "Principal stress 3"
+	default:
+		return "Unknown"
+	}
+}
+
+// NodalField returns values of the field at each node
+func (r *Result) NodalField(field Field) []float64 {
+	values := make([]float64, len(r.mesh.akt))
+	for i := range values {
+		switch field {
+		case FieldDisplacement:
+			u := r.Displacement(i)
+			values[i] = math.Sqrt(u[0]*u[0] + u[1]*u[1] + u[2]*u[2])
+		case FieldDisplacementX, FieldDisplacementY, FieldDisplacementZ:
+			values[i] = r.u[3*i+int(field-FieldDisplacementX)]
+		case FieldVonMises:
+			values[i] = r.nodalStress[i].VonMises()
+		case FieldPrincipal1, FieldPrincipal2, FieldPrincipal3:
+			principal, _ := r.nodalStress[i].Principal()
+			values[i] = principal[field-FieldPrincipal1]
+		}
+	}
+	return values
+}
@@ -0,0 +1,200 @@
+package fem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SolverMethod selects how linear system of the problem is solved
+type SolverMethod int
+
+// Available solver methods
+const (
+	SolverCG       SolverMethod = iota // Preconditioned conjugate gradient
+	SolverCholesky                     // Sparse LDLᵀ factorization, reused while stiffness matrix doesn't change
+)
+
+var solverMethodNames = [...]string{
+	SolverCG:       "cg",
+	SolverCholesky: "cholesky",
+}
+
+// SolverMethods lists all solver methods
+var SolverMethods = []SolverMethod{SolverCG, SolverCholesky}
+
+func (m SolverMethod) String() string {
+	if m < 0 || int(m) >= len(solverMethodNames) {
+		return "unknown"
+	}
+	return solverMethodNames[m]
+}
+
+// ParseSolverMethod returns solver method by its name
+func ParseSolverMethod(name string) (SolverMethod, error) {
+	for _, m := range SolverMethods {
+		if m.String() == name {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown solver %q, expected one of %s", name, strings.Join(solverMethodNames[:], ", "))
+}
+
+// errZeroPivot is returned when LDLᵀ factorization meets zero diagonal, matrix is singular
+var errZeroPivot = errors.New("zero pivot")
+
+// ldlFactor is a sparse factorization P * A * Pᵀ = L * D * Lᵀ of symmetric matrix A, where L is unit lower
+// triangular matrix stored by columns without the diagonal and D is diagonal
+type ldlFactor struct {
+	n    int
+	perm []int // perm[k] is row of A placed at row k of P * A * Pᵀ
+
+	colPtr []int     // Start of each column of L in rowIdx and values, n + 1
+	rowIdx []int     // Row indexes of L below the diagonal
+	values []float64 // Values of L below the diagonal
+	d      []float64 // Diagonal of D
+}
+
+// newLDL factorizes symmetric matrix m using up-looking algorithm with elimination tree, only the lower triangle of
+// m is used, so both its halves must be stored; progress of every column is passed to report, factorization stops
+// when ctx is done
+func newLDL(ctx context.Context, m *sparseMatrix, perm []int, report func(done, total int)) (*ldlFactor, error) {
+	n := m.n
+	permInv := make([]int, n)
+	for k, i := range perm {
+		permInv[i] = k
+	}
+
+	// Symbolic step, elimination tree and number of nonzeros in each column of L, row k of L consists of nodes on
+	// paths from rows of the upper triangle of column k up to k in the tree
+	parent := make([]int, n)
+	colCount := make([]int, n)
+	flag := make([]int, n)
+	for k := range n {
+		parent[k] = -1
+		flag[k] = k
+		row := perm[k]
+		for p := m.rowPtr[row]; p < m.rowPtr[row+1]; p++ {
+			for i := permInv[m.colIdx[p]]; i < k && flag[i] != k; i = parent[i] {
+				if parent[i] == -1 {
+					parent[i] = k
+				}
+				colCount[i]++
+				flag[i] = k
+			}
+		}
+	}
+
+	f := &ldlFactor{
+		n:      n,
+		perm:   perm,
+		colPtr: make([]int, n+1),
+		d:      make([]float64, n),
+	}
+	for k := range n {
+		f.colPtr[k+1] = f.colPtr[k] + colCount[k]
+	}
+	f.rowIdx = make([]int, f.colPtr[n])
+	f.values = make([]float64, f.colPtr[n])
+
+	// Numeric step, row k of L is found by sparse triangular solve with already computed columns
+	y := make([]float64, n)
+	pattern := make([]int, n)
+	clear(colCount)
+	for k := range n {
+		if k%1024 == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			report(k, n)
+		}
+
+		top := n
+		flag[k] = k
+		row := perm[k]
+		for p := m.rowPtr[row]; p < m.rowPtr[row+1]; p++ {
+			i := permInv[m.colIdx[p]]
+			if i > k {
+				continue
+			}
+			y[i] += m.values[p]
+
+			size := 0
+			for ; flag[i] != k; i = parent[i] {
+				pattern[size] = i
+				size++
+				flag[i] = k
+			}
+			for size > 0 {
+				top--
+				size--
+				pattern[top] = pattern[size]
+			}
+		}
+
+		f.d[k] = y[k]
+		y[k] = 0
+		for ; top < n; top++ {
+			i := pattern[top]
+			yi := y[i]
+			y[i] = 0
+
+			end := f.colPtr[i] + colCount[i]
+			for p := f.colPtr[i]; p < end; p++ {
+				y[f.rowIdx[p]] -= f.values[p] * yi
+			}
+
+			lki := yi / f.d[i]
+			f.d[k] -= lki * yi
+			f.rowIdx[end] = k
+			f.values[end] = lki
+			colCount[i]++
+		}
+
+		if f.d[k] == 0 {
+			return nil, fmt.Errorf("factorize row %d: %w", perm[k], errZeroPivot)
+		}
+	}
+	report(n, n)
+
+	return f, nil
+}
+
+// nonzeros returns number of stored entries of L including the diagonal
+func (f *ldlFactor) nonzeros() int {
+	return len(f.values) + f.n
+}
+
+// solve returns x such that A * x = b
+func (f *ldlFactor) solve(b []float64) []float64 {
+	y := make([]float64, f.n)
+	for k, i := range f.perm {
+		y[k] = b[i]
+	}
+
+	// L * z = P * b
+	for j := range f.n {
+		for p := f.colPtr[j]; p < f.colPtr[j+1]; p++ {
+			y[f.rowIdx[p]] -= f.values[p] * y[j]
+		}
+	}
+
+	// D * w = z
+	for j := range f.n {
+		y[j] /= f.d[j]
+	}
+
+	// Lᵀ * v = w
+	for j := f.n - 1; j >= 0; j-- {
+		for p := f.colPtr[j]; p < f.colPtr[j+1]; p++ {
+			y[j] -= f.values[p] * y[f.rowIdx[p]]
+		}
+	}
+
+	x := make([]float64, f.n)
+	for k, i := range f.perm {
+		x[i] = y[k]
+	}
+	return x
+}
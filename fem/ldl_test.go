@@ -0,0 +1,100 @@
+package fem
+
+import (
+	"context"
+	"math"
+	"slices"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestLDLSolve(t *testing.T) {
+	mesh, bc := newTestProblem(t)
+	ctx := context.Background()
+
+	f := New(mesh)
+	if err := f.assembleStiffness(ctx, UniformMaterials(testMaterial, len(mesh.elements))); err != nil {
+		t.Fatal(err)
+	}
+	m, err := applyConstraints(f.k, f.calculateConstraints(bc), ConstraintElimination)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	factor, err := newLDL(ctx, m, nestedDissection(m, mesh.akt), func(int, int) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := make([]float64, m.n)
+	for i := range b {
+		b[i] = math.Sin(float64(i))
+	}
+	x := factor.solve(b)
+
+	dense := mat.NewDense(m.n, m.n, nil)
+	for i := range m.n {
+		for p := m.rowPtr[i]; p < m.rowPtr[i+1]; p++ {
+			dense.Set(i, m.colIdx[p], m.values[p])
+		}
+	}
+	var expected mat.VecDense
+	if err = expected.SolveVec(dense, mat.NewVecDense(m.n, b)); err != nil {
+		t.Fatal(err)
+	}
+
+	diff := mat.NewVecDense(m.n, slices.Clone(x))
+	diff.SubVec(diff, &expected)
+	if e := mat.Norm(diff, 2) / mat.Norm(&expected, 2); e > 1e-10 {
+		t.Errorf("relative difference from dense solve %g", e)
+	}
+}
+
+func TestNestedDissectionPermutation(t *testing.T) {
+	mesh, _ := newTestProblem(t)
+	k := newStiffnessPattern(len(mesh.akt), mesh.nt)
+
+	perm := nestedDissection(k, mesh.akt)
+	if len(perm) != k.n {
+		t.Fatalf("permutation length %d, expected %d", len(perm), k.n)
+	}
+	seen := make([]bool, k.n)
+	for _, i := range perm {
+		if i < 0 || i >= k.n || seen[i] {
+			t.Fatalf("%d is out of range or repeated", i)
+		}
+		seen[i] = true
+	}
+}
+
+func TestCholeskyFactorizationCached(t *testing.T) {
+	mesh, bc := newTestProblem(t)
+	f := New(mesh)
+	f.Method = SolverCholesky
+
+	first, err := f.Solve(testMaterial, bc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !first.Stats().Factorized {
+		t.Error("first solve didn't factorize")
+	}
+
+	bc.Pressure = 3
+	second, err := f.Solve(testMaterial, bc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Stats().Factorized {
+		t.Error("solve with only pressure changed factorized again")
+	}
+
+	// Displacements are linear in pressure
+	u1, u2 := first.Displacements(), second.Displacements()
+	for i := range u1 {
+		if math.Abs(u2[i]-1.5*u1[i]) > 1e-9*(1+math.Abs(u2[i])) {
+			t.Fatalf("displacement %d is %g, expected %g", i, u2[i], 1.5*u1[i])
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package fem
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNormalTractionIsPressure(t *testing.T) {
+	mesh, bc := newTestProblem(t)
+
+	solve := func(t *testing.T, bc *BoundaryConditions) []float64 {
+		t.Helper()
+		f := New(mesh)
+		f.Method = SolverCholesky
+		result, err := f.Solve(testMaterial, bc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result.Displacements()
+	}
+	expected := solve(t, bc)
+
+	// Pressure pushes against outward normal, which is z for the top face
+	tractions := map[string]Traction{
+		"local":  {Vector: [3]float64{-bc.Pressure, 0, 0}, Local: true},
+		"global": {Vector: [3]float64{0, 0, -bc.Pressure}},
+	}
+	for name, traction := range tractions {
+		t.Run(name, func(t *testing.T) {
+			loaded := bc.Clone()
+			clear(loaded.Pushed)
+			for _, es := range mesh.FaceSides(5) {
+				loaded.Tractions[es] = traction
+			}
+			u := solve(t, loaded)
+
+			for i := range u {
+				if math.Abs(u[i]-expected[i]) > 1e-12 {
+					t.Fatalf("displacement %d is %g, expected %g", i, u[i], expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBodyForceTotal(t *testing.T) {
+	mesh, bc := newTestProblem(t)
+	bc.Pressure = 0
+	size := mesh.Size()
+	volume := size[0] * size[1] * size[2]
+
+	// Field linear in x adds its mean value over the body times the volume
+	const density, g = 2.5, -9.81
+	bc.BodyForce = BodyForce{
+		Density:      density,
+		Acceleration: [3]float64{0, 0, g},
+		Field:        func(x [3]float64) [3]float64 { return [3]float64{x[0], 0, 0} },
+	}
+	expected := [3]float64{size[0] / 2 * volume, 0, density * g * volume}
+
+	result, err := Solve(mesh, testMaterial, bc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	load := result.AppliedLoad()
+	for i := range 3 {
+		if math.Abs(load[i]-expected[i]) > 1e-9*math.Abs(expected[2]) {
+			t.Errorf("applied load %v, expected %v", load, expected)
+			break
+		}
+	}
+	if e := result.EquilibriumError(); e > 1e-6 {
+		t.Errorf("equilibrium error %g", e)
+	}
+}
+
+func TestNodalLoadReaction(t *testing.T) {
+	mesh, bc := newTestProblem(t)
+	bc.Pressure = 0
+
+	size := mesh.Size()
+	node := mesh.NearestNode(size)
+	force := [3]float64{1, -2, -3}
+	bc.NodalLoads[node] = force
+
+	result, err := Solve(mesh, testMaterial, bc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if load := result.AppliedLoad(); load != force {
+		t.Errorf("applied load %v, expected %v", load, force)
+	}
+
+	// The only fixed face balances force and its moment about origin
+	reactions := result.FaceReactions([3]float64{})
+	if len(reactions) != 1 {
+		t.Fatalf("%d faces have reactions, expected 1", len(reactions))
+	}
+	moment := cross(mesh.Nodes()[node], force)
+	for i := range 3 {
+		if math.Abs(reactions[0].Force[i]+force[i]) > 1e-6 || math.Abs(reactions[0].Moment[i]+moment[i]) > 1e-6 {
+			t.Fatalf("reaction force %v and moment %v, expected opposite to %v and %v",
+				reactions[0].Force, reactions[0].Moment, force, moment)
+		}
+	}
+}
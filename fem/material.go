@@ -0,0 +1,299 @@
+package fem
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// MaterialType selects constitutive law of linear elastic material
+type MaterialType int
+
+// Available material types
+const (
+	MaterialIsotropic   MaterialType = iota // Young's modulus and Poisson's ratio
+	MaterialOrthotropic                     // Nine engineering constants along material axes
+	MaterialAnisotropic                     // Full constitutive matrix
+)
+
+var materialTypeNames = [...]string{
+	MaterialIsotropic:   "isotropic",
+	MaterialOrthotropic: "orthotropic",
+	MaterialAnisotropic: "anisotropic",
+}
+
+// MaterialTypes lists all material types
+var MaterialTypes = []MaterialType{MaterialIsotropic, MaterialOrthotropic, MaterialAnisotropic}
+
+func (t MaterialType) String() string {
+	if t < 0 || int(t) >= len(materialTypeNames) {
+		return "unknown"
+	}
+	return materialTypeNames[t]
+}
+
+// ParseMaterialType returns material type by its name
+func ParseMaterialType(name string) (MaterialType, error) {
+	for _, t := range MaterialTypes {
+		if t.String() == name {
+			return t, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown material type %q, expected one of %s", name,
+		strings.Join(materialTypeNames[:], ", "))
+}
+
+// MarshalText encodes material type as its name
+func (t MaterialType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText decodes material type from its name
+func (t *MaterialType) UnmarshalText(text []byte) error {
+	parsed, err := ParseMaterialType(string(text))
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+// Material is a linear elastic material, only constants of its type are used
+type Material struct {
+	Type MaterialType `json:"type,omitzero"` // Isotropic if not set, as in scenarios before version 9
+
+	YoungsModulus float64 `json:"youngs_modulus,omitzero"`
+	PoissonRatio  float64 `json:"poisson_ratio,omitzero"`
+
+	Orthotropic Orthotropic `json:"orthotropic,omitzero"`
+
+	// Constitutive matrix of anisotropic material relating stress and strain in Voigt notation of Tensor, must be
+	// symmetric and positive definite
+	Stiffness [6][6]float64 `json:"stiffness,omitzero"`
+}
+
+// Orthotropic holds engineering constants of orthotropic material, indexes 1, 2 and 3 are material axes
+type Orthotropic struct {
+	E1   float64 `json:"e1"`
+	E2   float64 `json:"e2"`
+	E3   float64 `json:"e3"`
+	Nu12 float64 `json:"nu12"` // Contraction along axis 2 by extension along axis 1, nu21 = nu12 * E2 / E1
+	Nu13 float64 `json:"nu13"`
+	Nu23 float64 `json:"nu23"`
+	G12  float64 `json:"g12"`
+	G13  float64 `json:"g13"`
+	G23  float64 `json:"g23"`
+
+	// Material axes 1, 2 and 3 as orthonormal vectors in global coords, global x, y and z if not set
+	Axes [3][3]float64 `json:"axes,omitzero"`
+}
+
+// Validate checks that material constants are physically possible
+func (m Material) Validate() error {
+	switch m.Type {
+	case MaterialIsotropic:
+		if m.YoungsModulus <= 0 {
+			return fmt.Errorf("invalid Young's modulus %g", m.YoungsModulus)
+		}
+		if m.PoissonRatio <= -1 || m.PoissonRatio >= 0.5 {
+			return fmt.Errorf("invalid Poisson's ratio %g", m.PoissonRatio)
+		}
+		return nil
+	case MaterialOrthotropic:
+		o := m.Orthotropic
+		for _, v := range [...]float64{o.E1, o.E2, o.E3, o.G12, o.G13, o.G23} {
+			if v <= 0 {
+				return fmt.Errorf("invalid orthotropic moduli %g %g %g %g %g %g", o.E1, o.E2, o.E3, o.G12, o.G13, o.G23)
+			}
+		}
+		if o.Axes != ([3][3]float64{}) && !orthonormal(o.Axes) {
+			return fmt.Errorf("orthotropic axes %v are not orthonormal", o.Axes)
+		}
+		if !positiveDefinite(o.compliance()) {
+			return fmt.Errorf("orthotropic Poisson's ratios %g %g %g give not positive definite compliance",
+				o.Nu12, o.Nu13, o.Nu23)
+		}
+		return nil
+	case MaterialAnisotropic:
+		for i := range 6 {
+			for j := range i {
+				if math.Abs(m.Stiffness[i][j]-m.Stiffness[j][i]) > 1e-9*math.Abs(m.Stiffness[i][i]+m.Stiffness[j][j]) {
+					return fmt.Errorf("anisotropic stiffness is not symmetric at %d %d", i, j)
+				}
+			}
+		}
+		if !positiveDefinite(m.Stiffness) {
+			return fmt.Errorf("anisotropic stiffness is not positive definite")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown material type %d", m.Type)
+	}
+}
+
+// elasticity returns constitutive matrix relating stress and strain in Voigt notation of Tensor in global axes
+func (m Material) elasticity() [6][6]float64 {
+	switch m.Type {
+	case MaterialOrthotropic:
+		return m.Orthotropic.elasticity()
+	case MaterialAnisotropic:
+		return m.Stiffness
+	default:
+		e, nu := m.YoungsModulus, m.PoissonRatio
+		l := e / ((1 + nu) * (1 - 2*nu))
+		mu := e / (2 * (1 + nu))
+
+		var d [6][6]float64
+		for i := range 3 {
+			for j := range 3 {
+				d[i][j] = l * nu
+			}
+			d[i][i] = l * (1 - nu)
+			d[3+i][3+i] = mu
+		}
+		return d
+	}
+}
+
+// compliance returns compliance matrix in material axes
+func (o Orthotropic) compliance() [6][6]float64 {
+	var s [6][6]float64
+	s[0][0], s[1][1], s[2][2] = 1/o.E1, 1/o.E2, 1/o.E3
+	s[0][1], s[1][0] = -o.Nu12/o.E1, -o.Nu12/o.E1
+	s[0][2], s[2][0] = -o.Nu13/o.E1, -o.Nu13/o.E1
+	s[1][2], s[2][1] = -o.Nu23/o.E2, -o.Nu23/o.E2
+	s[3][3], s[4][4], s[5][5] = 1/o.G12, 1/o.G23, 1/o.G13
+	return s
+}
+
+// elasticity returns constitutive matrix in global axes, inverse of compliance rotated from material axes
+func (o Orthotropic) elasticity() [6][6]float64 {
+	s := o.compliance()
+	var inverse mat.Dense
+	// Compliance is checked to be positive definite by Validate, so it is invertible
+	_ = inverse.Inverse(mat.NewDense(6, 6, flatten(s)))
+
+	var local [6][6]float64
+	for i := range 6 {
+		for j := range 6 {
+			local[i][j] = inverse.At(i, j)
+		}
+	}
+	if o.Axes == ([3][3]float64{}) {
+		return local
+	}
+
+	// Strain energy is the same in both axes, so D = Tᵀ * D' * T where T maps global strain to material one
+	t := strainRotation(o.Axes)
+	var d [6][6]float64
+	for i := range 6 {
+		for j := range 6 {
+			for k := range 6 {
+				for l := range 6 {
+					d[i][j] += t[k][i] * local[k][l] * t[l][j]
+				}
+			}
+		}
+	}
+	return d
+}
+
+// voigtPairs are tensor indexes of Voigt components xx, yy, zz, xy, yz, zx
+var voigtPairs = [6][2]int{{0, 0}, {1, 1}, {2, 2}, {0, 1}, {1, 2}, {2, 0}}
+
+// strainRotation returns matrix that maps strain in Voigt notation from global axes to axes given by rows of r
+func strainRotation(r [3][3]float64) [6][6]float64 {
+	var t [6][6]float64
+	for p, ab := range voigtPairs {
+		a, b := ab[0], ab[1]
+		for q, ij := range voigtPairs {
+			i, j := ij[0], ij[1]
+			if i == j {
+				t[p][q] = r[a][i] * r[b][i]
+			} else {
+				// Engineering shear strain is twice the tensor component, which is split between ij and ji
+				t[p][q] = (r[a][i]*r[b][j] + r[a][j]*r[b][i]) / 2
+			}
+			if a != b {
+				t[p][q] *= 2
+			}
+		}
+	}
+	return t
+}
+
+// orthonormal reports whether rows of r are orthogonal unit vectors
+func orthonormal(r [3][3]float64) bool {
+	const eps = 1e-6
+	for i := range 3 {
+		for j := range 3 {
+			dot := r[i][0]*r[j][0] + r[i][1]*r[j][1] + r[i][2]*r[j][2]
+			if i == j {
+				dot--
+			}
+			if math.Abs(dot) > eps {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// positiveDefinite reports whether symmetric matrix has Cholesky factorization
+func positiveDefinite(m [6][6]float64) bool {
+	var chol mat.Cholesky
+	return chol.Factorize(mat.NewSymDense(6, flatten(m)))
+}
+
+func flatten(m [6][6]float64) []float64 {
+	flat := make([]float64, 0, 36)
+	for _, row := range m {
+		flat = append(flat, row[:]...)
+	}
+	return flat
+}
+
+// Materials is a table of materials with material of every element, for bodies made of few materials
+type Materials struct {
+	Table    []Material // Materials referenced by elements
+	Elements []int      // Index of material in Table of each element
+}
+
+// UniformMaterials returns materials of body with given number of elements made of one material
+func UniformMaterials(material Material, elements int) *Materials {
+	return &Materials{Table: []Material{material}, Elements: make([]int, elements)}
+}
+
+// Validate checks that all materials are physically possible and every element of the body references one of them
+func (m *Materials) Validate(elements int) error {
+	if len(m.Table) == 0 {
+		return fmt.Errorf("no materials")
+	}
+	for i, material := range m.Table {
+		if err := material.Validate(); err != nil {
+			return fmt.Errorf("material %d: %w", i, err)
+		}
+	}
+	if len(m.Elements) != elements {
+		return fmt.Errorf("materials of %d elements given for %d elements", len(m.Elements), elements)
+	}
+	for i, index := range m.Elements {
+		if index < 0 || index >= len(m.Table) {
+			return fmt.Errorf("invalid material %d of element %d", index, i)
+		}
+	}
+	return nil
+}
+
+// Clone returns deep copy of materials
+func (m *Materials) Clone() *Materials {
+	return &Materials{Table: slices.Clone(m.Table), Elements: slices.Clone(m.Elements)}
+}
+
+// element returns material of the element
+func (m *Materials) element(i int) Material {
+	return m.Table[m.Elements[i]]
+}
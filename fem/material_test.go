@@ -0,0 +1,160 @@
+package fem
+
+import (
+	"context"
+	"math"
+	"slices"
+	"testing"
+)
+
+// maxDisplacement returns the largest displacement component by absolute value
+func maxDisplacement(r *Result) float64 {
+	var m float64
+	for _, v := range r.Displacements() {
+		m = max(m, math.Abs(v))
+	}
+	return m
+}
+
+func TestUniformMaterials(t *testing.T) {
+	mesh, bc := newTestProblem(t)
+
+	expected, err := Solve(mesh, testMaterial, bc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Middle layer references the second entry of the table equal to the first one
+	same := UniformMaterials(testMaterial, len(mesh.elements))
+	same.Table = append(same.Table, testMaterial)
+	for _, i := range mesh.LayerElements(2, 1) {
+		same.Elements[i] = 1
+	}
+
+	tables := map[string]*Materials{
+		"uniform": UniformMaterials(testMaterial, len(mesh.elements)),
+		"same":    same,
+	}
+	for name, materials := range tables {
+		t.Run(name, func(t *testing.T) {
+			result, err := New(mesh).SolveMaterials(context.Background(), materials, bc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(result.Displacements(), expected.Displacements()) {
+				t.Error("displacements differ from single material ones")
+			}
+		})
+	}
+}
+
+func TestTwoMaterials(t *testing.T) {
+	mesh, bc := newTestProblem(t)
+
+	uniform, err := Solve(mesh, testMaterial, bc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		modulus float64 // Young's modulus of the middle layer
+		stiffer bool
+	}{
+		{"stiff layer", 10 * testMaterial.YoungsModulus, true},
+		{"soft layer", testMaterial.YoungsModulus / 10, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layer := testMaterial
+			layer.YoungsModulus = tt.modulus
+			materials := UniformMaterials(testMaterial, len(mesh.elements))
+			materials.Table = append(materials.Table, layer)
+			for _, i := range mesh.LayerElements(2, 1) {
+				materials.Elements[i] = 1
+			}
+
+			result, err := New(mesh).SolveMaterials(context.Background(), materials, bc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if u, uniformU := maxDisplacement(result), maxDisplacement(uniform); (u < uniformU) != tt.stiffer {
+				t.Errorf("max displacement %g, uniform body %g", u, uniformU)
+			}
+		})
+	}
+}
+
+func TestOrthotropicIsotropic(t *testing.T) {
+	mesh, bc := newTestProblem(t)
+	e, nu := testMaterial.YoungsModulus, testMaterial.PoissonRatio
+	g := e / (2 * (1 + nu))
+	orthotropic := Material{Type: MaterialOrthotropic, Orthotropic: Orthotropic{
+		E1: e, E2: e, E3: e, Nu12: nu, Nu13: nu, Nu23: nu, G12: g, G13: g, G23: g,
+	}}
+	if err := orthotropic.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := Solve(mesh, testMaterial, bc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := Solve(mesh, orthotropic, bc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u, expectedU := result.Displacements(), expected.Displacements()
+	scale := maxDisplacement(expected)
+	for i := range u {
+		if math.Abs(u[i]-expectedU[i]) > 1e-9*scale {
+			t.Fatalf("displacement %d is %g, expected %g", i, u[i], expectedU[i])
+		}
+	}
+}
+
+func TestRotatedOrthotropic(t *testing.T) {
+	// Material axes are global ones rotated about (1, 1, 1) by 40 degrees
+	angle := 40 * math.Pi / 180
+	axis := [3]float64{1 / math.Sqrt(3), 1 / math.Sqrt(3), 1 / math.Sqrt(3)}
+	var axes [3][3]float64
+	for i := range 3 {
+		for j := range 3 {
+			axes[i][j] = axis[i] * axis[j] * (1 - math.Cos(angle))
+			if i == j {
+				axes[i][j] += math.Cos(angle)
+			}
+		}
+	}
+	axes[0][1] -= axis[2] * math.Sin(angle)
+	axes[1][0] += axis[2] * math.Sin(angle)
+	axes[0][2] += axis[1] * math.Sin(angle)
+	axes[2][0] -= axis[1] * math.Sin(angle)
+	axes[1][2] -= axis[0] * math.Sin(angle)
+	axes[2][1] += axis[0] * math.Sin(angle)
+
+	material := Material{Type: MaterialOrthotropic, Orthotropic: Orthotropic{
+		E1: 5, E2: 10, E3: 2, Nu12: 0.2, Nu13: 0.4, Nu23: 0.1, G12: 1, G13: 1.5, G23: 0.8, Axes: axes,
+	}}
+	if err := material.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	d := material.elasticity()
+	for i := range 6 {
+		for j := range i {
+			if math.Abs(d[i][j]-d[j][i]) > 1e-12*math.Abs(d[i][i]) {
+				t.Errorf("d[%d][%d] = %g, d[%d][%d] = %g", i, j, d[i][j], j, i, d[j][i])
+			}
+		}
+	}
+	if !positiveDefinite(d) {
+		t.Error("rotated constitutive matrix is not positive definite")
+	}
+
+	// Rotation mixes normal and shear components
+	if d[0][3] == 0 {
+		t.Error("rotated constitutive matrix has no normal-shear coupling")
+	}
+}
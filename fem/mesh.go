@@ -0,0 +1,317 @@
+package fem
+
+import (
+	"fmt"
+	"math"
+)
+
+// Mesh is a box shaped body split into 20-node hexahedral elements
+type Mesh struct {
+	size  [3]float64 // Size of the body (x, y, z)
+	split [3]int     // Number of elements along each axis (x, y, z)
+
+	elements [][20][3]float64 // Coords of grid vertices in local space, npq * 20 * 3 (x, y, z)
+	akt      [][3]float64     // Coords of grid vertices in global space, npq * 3 (x, y, z)
+	nt       [][20]int        // Local element indexes, npq * 20
+
+	indexMapping map[[3]int]int // Indexes of surface vertices by their coords in half steps
+}
+
+// NewMesh splits body of given size into elements
+func NewMesh(bodySize [3]float64, bodySplit [3]int) (*Mesh, error) {
+	for i := range 3 {
+		if bodySize[i] <= 0 {
+			return nil, fmt.Errorf("invalid body size %v", bodySize)
+		}
+		if bodySplit[i] < 1 {
+			return nil, fmt.Errorf("invalid body split %v", bodySplit)
+		}
+	}
+
+	m := &Mesh{
+		size:  bodySize,
+		split: bodySplit,
+	}
+
+	stepA := bodySize[0] / float64(bodySplit[0])
+	stepB := bodySize[1] / float64(bodySplit[1])
+	stepC := bodySize[2] / float64(bodySplit[2])
+
+	for k := range bodySplit[2] {
+		for j := range bodySplit[1] {
+			for i := range bodySplit[0] {
+				m.elements = append(m.elements, createCube(
+					float64(i)*stepA, float64(i+1)*stepA,
+					float64(j)*stepB, float64(j+1)*stepB,
+					float64(k)*stepC, float64(k+1)*stepC,
+				))
+			}
+		}
+	}
+
+	const showInternal = false
+	m.indexMapping = make(map[[3]int]int)
+	gridMapping := make(map[[3]int]int) // Index of each vertex by its coords in half steps
+	for k := range 2*bodySplit[2] + 1 {
+		if k%2 == 0 {
+			for j := range 2*bodySplit[1] + 1 {
+				if j%2 == 0 {
+					for i := range 2*bodySplit[0] + 1 {
+						if showInternal || i == 0 || j == 0 || k == 0 || i == 2*bodySplit[0] || j == 2*bodySplit[1] || k == 2*bodySplit[2] {
+							m.indexMapping[[3]int{i, j, k}] = len(m.akt)
+						}
+						gridMapping[[3]int{i, j, k}] = len(m.akt)
+						m.akt = append(m.akt, [3]float64{float64(i) * stepA / 2, float64(j) * stepB / 2, float64(k) * stepC / 2})
+					}
+				} else {
+					for i := range bodySplit[0] + 1 {
+						if showInternal || i == 0 || j == 0 || k == 0 || i == bodySplit[0] || j == 2*bodySplit[1] || k == 2*bodySplit[2] {
+							m.indexMapping[[3]int{i * 2, j, k}] = len(m.akt)
+						}
+						gridMapping[[3]int{i * 2, j, k}] = len(m.akt)
+						m.akt = append(m.akt, [3]float64{float64(i) * stepA, float64(j) * stepB / 2, float64(k) * stepC / 2})
+					}
+				}
+			}
+		} else {
+			for j := range bodySplit[1] + 1 {
+				for i := range bodySplit[0] + 1 {
+					if showInternal || i == 0 || j == 0 || k == 0 || i == bodySplit[0] || j == bodySplit[1] || k == 2*bodySplit[2] {
+						m.indexMapping[[3]int{i * 2, j * 2, k}] = len(m.akt)
+					}
+					gridMapping[[3]int{i * 2, j * 2, k}] = len(m.akt)
+					m.akt = append(m.akt, [3]float64{float64(i) * stepA, float64(j) * stepB, float64(k) * stepC / 2})
+				}
+			}
+		}
+	}
+
+	for e, cube := range m.elements {
+		var ntCube [20]int
+		for i, p := range cube {
+			key := [3]int{
+				int(math.Round(p[0] / (stepA / 2))),
+				int(math.Round(p[1] / (stepB / 2))),
+				int(math.Round(p[2] / (stepC / 2))),
+			}
+			j, ok := gridMapping[key]
+			if !ok {
+				return nil, fmt.Errorf("not found NT index of element %d, local node %d at %v", e, i, p)
+			}
+			ntCube[i] = j
+		}
+		m.nt = append(m.nt, ntCube)
+	}
+
+	return m, nil
+}
+
+// nodeCount returns number of nodes of the mesh with given split, vertices of the grid and midpoints of its edges
+func nodeCount(split [3]int) int {
+	a, b, c := split[0], split[1], split[2]
+	return (a+1)*(b+1)*(c+1) + a*(b+1)*(c+1) + (a+1)*b*(c+1) + (a+1)*(b+1)*c
+}
+
+// Size returns size of the body
+func (m *Mesh) Size() [3]float64 {
+	return m.size
+}
+
+// Split returns number of elements along each axis
+func (m *Mesh) Split() [3]int {
+	return m.split
+}
+
+// Nodes returns coords of all grid vertices, must not be modified
+func (m *Mesh) Nodes() [][3]float64 {
+	return m.akt
+}
+
+// NearestNode returns index of the node closest to the point, the first one if there are few
+func (m *Mesh) NearestNode(point [3]float64) int {
+	nearest, nearestDist := 0, math.Inf(1)
+	for i, p := range m.akt {
+		var dist float64
+		for xyz := range 3 {
+			dist += (p[xyz] - point[xyz]) * (p[xyz] - point[xyz])
+		}
+		if dist < nearestDist {
+			nearest, nearestDist = i, dist
+		}
+	}
+	return nearest
+}
+
+// Elements returns coords of vertices of each element, must not be modified
+func (m *Mesh) Elements() [][20][3]float64 {
+	return m.elements
+}
+
+// ElementNodes returns indexes of vertices of each element, must not be modified
+func (m *Mesh) ElementNodes() [][20]int {
+	return m.nt
+}
+
+// SurfaceIndexes returns indexes of surface vertices by their coords in half steps, must not be modified
+func (m *Mesh) SurfaceIndexes() map[[3]int]int {
+	return m.indexMapping
+}
+
+// Side returns coords of 8 vertices of element side, 4 corners followed by 4 middle points
+func (m *Mesh) Side(es ElementSide) [8][3]float64 {
+	return choseCubeSide(m.elements[es.Element], es.Side)
+}
+
+// SideNodes returns indexes of 8 vertices of element side in the same order as Side
+func (m *Mesh) SideNodes(es ElementSide) [8]int {
+	var nodes [8]int
+	for i, j := range cubeSideIndexes(m.elements[es.Element], es.Side) {
+		nodes[i] = m.nt[es.Element][j]
+	}
+	return nodes
+}
+
+// ElementPosition returns position of the element in the grid along x, y and z
+func (m *Mesh) ElementPosition(element int) [3]int {
+	a, b := m.split[0], m.split[1]
+	return [3]int{element % a, element / a % b, element / (a * b)}
+}
+
+// LayerElements returns elements at given position along the axis, like all elements of the bottom layer for axis
+// 2 and layer 0
+func (m *Mesh) LayerElements(axis, layer int) []int {
+	var elements []int
+	for i := range m.elements {
+		if m.ElementPosition(i)[axis] == layer {
+			elements = append(elements, i)
+		}
+	}
+	return elements
+}
+
+// BoundarySides returns sides of all elements that lie on the surface of the body
+func (m *Mesh) BoundarySides() []ElementSide {
+	var sides []ElementSide
+	for side := range 6 {
+		sides = append(sides, m.FaceSides(side)...)
+	}
+	return sides
+}
+
+// FaceSides returns sides of all elements that lie on the side of the body, side is numbered the same way as in
+// ElementSide
+func (m *Mesh) FaceSides(side int) []ElementSide {
+	a, b, c := m.split[0], m.split[1], m.split[2]
+
+	var sides []ElementSide
+	for k := range c {
+		for j := range b {
+			for i := range a {
+				onFace := false
+				switch side {
+				case 0:
+					onFace = i == 0
+				case 1:
+					onFace = i == a-1
+				case 2:
+					onFace = j == 0
+				case 3:
+					onFace = j == b-1
+				case 4:
+					onFace = k == 0
+				case 5:
+					onFace = k == c-1
+				}
+				if onFace {
+					sides = append(sides, ElementSide{Element: i + a*j + a*b*k, Side: side})
+				}
+			}
+		}
+	}
+	return sides
+}
+
+func createCube(aStart, aEnd, bStart, bEnd, cStart, cEnd float64) [20][3]float64 {
+	aSize := aEnd - aStart
+	bSize := bEnd - bStart
+	cSize := cEnd - cStart
+
+	x := [20]float64{aStart, aEnd, aEnd, aStart, aStart, aEnd, aEnd, aStart,
+		aStart + aSize/2, aEnd, aStart + aSize/2, aStart,
+		aStart, aEnd, aEnd, aStart, aStart + aSize/2, aEnd,
+		aStart + aSize/2, aStart}
+
+	y := [20]float64{bStart, bStart, bEnd, bEnd, bStart, bStart, bEnd, bEnd,
+		bStart, bStart + bSize/2, bEnd, bStart + bSize/2,
+		bStart, bStart, bEnd, bEnd, bStart, bStart + bSize/2,
+		bEnd, bStart + bSize/2}
+
+	z := [20]float64{cStart, cStart, cStart, cStart, cEnd, cEnd, cEnd, cEnd,
+		cStart, cStart, cStart, cStart, cStart + cSize/2,
+		cStart + cSize/2, cStart + cSize/2, cStart + cSize/2,
+		cEnd, cEnd, cEnd, cEnd}
+
+	var cube [20][3]float64
+	for i := range 20 {
+		cube[i] = [3]float64{x[i], y[i], z[i]}
+	}
+
+	return cube
+}
+
+func choseCubeSide(cube [20][3]float64, n int) [8][3]float64 {
+	var points [8][3]float64
+	for i, j := range cubeSideIndexes(cube, n) {
+		points[i] = cube[j]
+	}
+	return points
+}
+
+// cubeSideIndexes returns local indexes of 8 vertices of cube side, 4 corners followed by 4 middle points, where
+// middle point i lies between corners i and i + 1
+func cubeSideIndexes(cube [20][3]float64, n int) [8]int {
+	sideOfAxis := n / 2
+	var coordValue float64
+	if n%2 == 0 {
+		coordValue = math.MaxFloat64
+		for _, point := range cube {
+			coordValue = min(point[sideOfAxis], coordValue)
+		}
+	} else {
+		coordValue = -math.MaxFloat64
+		for _, point := range cube {
+			coordValue = max(point[sideOfAxis], coordValue)
+		}
+	}
+
+	i := 0
+	var indexes [8]int
+	for j, point := range cube {
+		if point[sideOfAxis] == coordValue {
+			indexes[i] = j
+			i++
+		}
+	}
+
+	switch n {
+	case 0:
+		indexes[0], indexes[1] = indexes[1], indexes[0]
+		indexes[6], indexes[7] = indexes[7], indexes[6]
+	case 1:
+		indexes[2], indexes[3] = indexes[3], indexes[2]
+		indexes[5], indexes[6], indexes[7] = indexes[6], indexes[7], indexes[5]
+	case 2:
+		indexes[2], indexes[3] = indexes[3], indexes[2]
+		indexes[5], indexes[6], indexes[7] = indexes[6], indexes[7], indexes[5]
+	case 3:
+		indexes[2], indexes[3] = indexes[3], indexes[2]
+		indexes[5], indexes[6], indexes[7] = indexes[6], indexes[7], indexes[5]
+	case 4:
+		indexes[0], indexes[1], indexes[2], indexes[3] = indexes[3], indexes[2], indexes[1], indexes[0]
+		indexes[4], indexes[6] = indexes[6], indexes[4]
+	case 5:
+		// OK
+	}
+
+	return indexes
+}
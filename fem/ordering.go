@@ -0,0 +1,115 @@
+package fem
+
+import (
+	"cmp"
+	"slices"
+)
+
+// nestedDissectionLeaf is the size of node sets that are ordered as is, without further dissection
+const nestedDissectionLeaf = 64
+
+// nestedDissection returns fill-reducing order of unknowns of the stiffness matrix m for nodes at coords. Node sets
+// are recursively cut in halves by a plane across their longest extent, nodes adjacent to the other half form the
+// separator that goes after both halves, unknowns of each node are kept together
+func nestedDissection(m *sparseMatrix, coords [][3]float64) []int {
+	nodes := m.n / 3
+
+	// Nodes are adjacent when they share an element, which is exactly the block pattern of the matrix
+	adjacency := make([][]int, nodes)
+	for i := range nodes {
+		row := 3 * i
+		for p := m.rowPtr[row]; p < m.rowPtr[row+1]; p += 3 {
+			if j := m.colIdx[p] / 3; j != i {
+				adjacency[i] = append(adjacency[i], j)
+			}
+		}
+	}
+
+	d := &dissection{
+		coords:    coords,
+		adjacency: adjacency,
+		part:      make([]int, nodes),
+		order:     make([]int, 0, nodes),
+	}
+	all := make([]int, nodes)
+	for i := range all {
+		all[i] = i
+	}
+	d.dissect(all)
+
+	perm := make([]int, 0, m.n)
+	for _, node := range d.order {
+		perm = append(perm, 3*node+0, 3*node+1, 3*node+2)
+	}
+	return perm
+}
+
+type dissection struct {
+	coords    [][3]float64
+	adjacency [][]int
+	part      []int // Id of the second half node belongs to, used to find edges crossing the cut
+	partID    int
+	order     []int
+}
+
+// dissect appends nodes of the set to the order so that separator goes after both halves
+func (d *dissection) dissect(set []int) {
+	if len(set) <= nestedDissectionLeaf {
+		d.order = append(d.order, set...)
+		return
+	}
+
+	// Cut across the longest extent of the set
+	minCoord, maxCoord := d.coords[set[0]], d.coords[set[0]]
+	for _, node := range set {
+		for axis := range 3 {
+			minCoord[axis] = min(minCoord[axis], d.coords[node][axis])
+			maxCoord[axis] = max(maxCoord[axis], d.coords[node][axis])
+		}
+	}
+	axis := 0
+	for i := range 3 {
+		if maxCoord[i]-minCoord[i] > maxCoord[axis]-minCoord[axis] {
+			axis = i
+		}
+	}
+
+	sorted := slices.Clone(set)
+	slices.SortStableFunc(sorted, func(a, b int) int { return cmp.Compare(d.coords[a][axis], d.coords[b][axis]) })
+	median := d.coords[sorted[len(sorted)/2]][axis]
+	cut, _ := slices.BinarySearchFunc(sorted, median, func(node int, v float64) int {
+		if d.coords[node][axis] <= v {
+			return -1
+		}
+		return 1
+	})
+	if cut == len(sorted) {
+		// All nodes are not greater than median, so cut before it
+		cut, _ = slices.BinarySearchFunc(sorted, median, func(node int, v float64) int {
+			return cmp.Compare(d.coords[node][axis], v)
+		})
+	}
+	if cut == 0 || cut == len(sorted) {
+		d.order = append(d.order, set...)
+		return
+	}
+
+	d.partID++
+	for _, node := range sorted[cut:] {
+		d.part[node] = d.partID
+	}
+
+	var first, separator []int
+	for _, node := range sorted[:cut] {
+		if slices.ContainsFunc(d.adjacency[node], func(neighbor int) bool { return d.part[neighbor] == d.partID }) {
+			separator = append(separator, node)
+		} else {
+			first = append(first, node)
+		}
+	}
+	second := sorted[cut:]
+
+	d.dissect(first)
+	d.dissect(second)
+	d.order = append(d.order, separator...)
+}
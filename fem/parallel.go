@@ -0,0 +1,33 @@
+package fem
+
+import (
+	"runtime"
+	"sync"
+)
+
+// parallelFor calls fn for each index in [0, n) using at most workers goroutines, if workers is not positive
+// GOMAXPROCS is used
+func parallelFor(n, workers int, fn func(i int)) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, n)
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+
+	for i := range n {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
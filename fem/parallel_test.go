@@ -0,0 +1,37 @@
+package fem
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestWorkersSameResult(t *testing.T) {
+	mesh, bc := newTestProblem(t)
+
+	var displacements [][]float64
+	for _, workers := range []int{1, 8} {
+		f := New(mesh)
+		f.Workers = workers
+		result, err := f.Solve(testMaterial, bc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		displacements = append(displacements, result.Displacements())
+	}
+
+	if !slices.Equal(displacements[0], displacements[1]) {
+		t.Error("displacements with 1 and 8 workers differ")
+	}
+}
+
+func TestParallelFor(t *testing.T) {
+	for _, workers := range []int{0, 1, 3, 100} {
+		calls := make([]int, 10)
+		parallelFor(len(calls), workers, func(i int) { calls[i]++ })
+		for i, c := range calls {
+			if c != 1 {
+				t.Errorf("workers %d: index %d called %d times", workers, i, c)
+			}
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package fem
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Preconditioner selects preconditioner of the conjugate gradient solver
+type Preconditioner int
+
+// Available preconditioners
+const (
+	PreconditionerNone               Preconditioner = iota // Plain conjugate gradient
+	PreconditionerJacobi                                   // Inverse of the diagonal
+	PreconditionerBlockJacobi                              // Inverse of 3x3 diagonal blocks of each node
+	PreconditionerIncompleteCholesky                       // Incomplete Cholesky factorization without fill-in, IC(0)
+)
+
+var preconditionerNames = [...]string{
+	PreconditionerNone:               "none",
+	PreconditionerJacobi:             "jacobi",
+	PreconditionerBlockJacobi:        "block-jacobi",
+	PreconditionerIncompleteCholesky: "ic0",
+}
+
+// Preconditioners lists all preconditioners
+var Preconditioners = []Preconditioner{
+	PreconditionerNone,
+	PreconditionerJacobi,
+	PreconditionerBlockJacobi,
+	PreconditionerIncompleteCholesky,
+}
+
+func (p Preconditioner) String() string {
+	if p < 0 || int(p) >= len(preconditionerNames) {
+		return "unknown"
+	}
+	return preconditionerNames[p]
+}
+
+// ParsePreconditioner returns preconditioner by its name
+func ParsePreconditioner(name string) (Preconditioner, error) {
+	for _, p := range Preconditioners {
+		if p.String() == name {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown preconditioner %q, expected one of %s", name,
+		strings.Join(preconditionerNames[:], ", "))
+}
+
+// preconSolve matches linsolve.Settings.PreconSolve
+type preconSolve func(dst *mat.VecDense, trans bool, rhs mat.Vector) error
+
+// newPreconSolve builds preconditioner of the stiffness matrix, nil means no preconditioning
+func newPreconSolve(p Preconditioner, m *sparseMatrix) (preconSolve, error) {
+	switch p {
+	case PreconditionerNone:
+		return nil, nil
+	case PreconditionerJacobi:
+		return m.jacobi()
+	case PreconditionerBlockJacobi:
+		return m.blockJacobi()
+	case PreconditionerIncompleteCholesky:
+		return m.incompleteCholesky()
+	default:
+		return nil, fmt.Errorf("unknown preconditioner %d", p)
+	}
+}
+
+// errNotPositiveDefinite is returned when preconditioner requires positive diagonal, but the matrix doesn't have it
+var errNotPositiveDefinite = errors.New("matrix is not positive definite")
+
+// vecData returns raw data of the vector, copying it if needed
+func vecData(v mat.Vector) []float64 {
+	vec, ok := v.(*mat.VecDense)
+	if !ok || vec.RawVector().Inc != 1 {
+		vec = mat.VecDenseCopyOf(v)
+	}
+	return vec.RawVector().Data
+}
+
+func (m *sparseMatrix) jacobi() (preconSolve, error) {
+	inv := make([]float64, m.n)
+	for i := range m.n {
+		d := m.At(i, i)
+		if d <= 0 {
+			return nil, fmt.Errorf("jacobi: row %d: %w", i, errNotPositiveDefinite)
+		}
+		inv[i] = 1 / d
+	}
+
+	return func(dst *mat.VecDense, _ bool, rhs mat.Vector) error {
+		r := vecData(rhs)
+		for i, v := range inv {
+			dst.SetVec(i, v*r[i])
+		}
+		return nil
+	}, nil
+}
+
+func (m *sparseMatrix) blockJacobi() (preconSolve, error) {
+	// Diagonal blocks are symmetric, so the same inverse is used for transposed solve
+	inv := make([][3][3]float64, m.n/3)
+	for node := range inv {
+		var block [3][3]float64
+		for i := range 3 {
+			for j := range 3 {
+				block[i][j] = m.At(3*node+i, 3*node+j)
+			}
+		}
+
+		var ok bool
+		inv[node], ok = inverse3(block)
+		if !ok {
+			return nil, fmt.Errorf("block jacobi: node %d: %w", node, errNotPositiveDefinite)
+		}
+	}
+
+	return func(dst *mat.VecDense, _ bool, rhs mat.Vector) error {
+		r := vecData(rhs)
+		for node, b := range inv {
+			x, y, z := r[3*node], r[3*node+1], r[3*node+2]
+			for i := range 3 {
+				dst.SetVec(3*node+i, b[i][0]*x+b[i][1]*y+b[i][2]*z)
+			}
+		}
+		return nil
+	}, nil
+}
+
+// inverse3 inverts 3x3 matrix using cofactors, false is returned for singular matrix
+func inverse3(a [3][3]float64) ([3][3]float64, bool) {
+	c := [3][3]float64{
+		{a[1][1]*a[2][2] - a[1][2]*a[2][1], a[0][2]*a[2][1] - a[0][1]*a[2][2], a[0][1]*a[1][2] - a[0][2]*a[1][1]},
+		{a[1][2]*a[2][0] - a[1][0]*a[2][2], a[0][0]*a[2][2] - a[0][2]*a[2][0], a[0][2]*a[1][0] - a[0][0]*a[1][2]},
+		{a[1][0]*a[2][1] - a[1][1]*a[2][0], a[0][1]*a[2][0] - a[0][0]*a[2][1], a[0][0]*a[1][1] - a[0][1]*a[1][0]},
+	}
+	det := a[0][0]*c[0][0] + a[0][1]*c[1][0] + a[0][2]*c[2][0]
+	if det == 0 || math.IsNaN(det) {
+		return [3][3]float64{}, false
+	}
+
+	for i := range 3 {
+		for j := range 3 {
+			c[i][j] /= det
+		}
+	}
+	return c, true
+}
+
+// incompleteCholesky computes lower triangular L with the same sparsity as lower part of the matrix, so that
+// L * Lᵀ approximates it, and solves with L and Lᵀ
+func (m *sparseMatrix) incompleteCholesky() (preconSolve, error) {
+	// Lower part of the matrix including diagonal, diagonal is the last entry of each row as columns are sorted
+	l := &sparseMatrix{n: m.n, rowPtr: make([]int, m.n+1)}
+	for i := range m.n {
+		for k := m.rowPtr[i]; k < m.rowPtr[i+1] && m.colIdx[k] <= i; k++ {
+			l.colIdx = append(l.colIdx, m.colIdx[k])
+			l.values = append(l.values, m.values[k])
+		}
+		l.rowPtr[i+1] = len(l.colIdx)
+	}
+
+	// Row i of L is scattered into work, so dot products with previous rows take one pass over them
+	work := make([]float64, m.n)
+	for i := range m.n {
+		start, diag := l.rowPtr[i], l.rowPtr[i+1]-1
+		if l.colIdx[diag] != i {
+			return nil, fmt.Errorf("incomplete cholesky: row %d has no diagonal: %w", i, errNotPositiveDefinite)
+		}
+
+		for k := start; k < diag; k++ {
+			j := l.colIdx[k]
+
+			sum := l.values[k]
+			jDiag := l.rowPtr[j+1] - 1
+			for kj := l.rowPtr[j]; kj < jDiag; kj++ {
+				sum -= l.values[kj] * work[l.colIdx[kj]]
+			}
+
+			l.values[k] = sum / l.values[jDiag]
+			work[j] = l.values[k]
+		}
+
+		d := l.values[diag]
+		for k := start; k < diag; k++ {
+			d -= l.values[k] * l.values[k]
+		}
+		if d <= 0 {
+			// Dropped fill-in can make pivot non-positive, original diagonal keeps factorization usable
+			d = m.At(i, i)
+			if d <= 0 {
+				return nil, fmt.Errorf("incomplete cholesky: row %d: %w", i, errNotPositiveDefinite)
+			}
+		}
+		l.values[diag] = math.Sqrt(d)
+
+		for k := start; k < diag; k++ {
+			work[l.colIdx[k]] = 0
+		}
+	}
+
+	// L * Lᵀ is symmetric, so the same solve is used for transposed system
+	return func(dst *mat.VecDense, _ bool, rhs mat.Vector) error {
+		x := make([]float64, l.n)
+		copy(x, vecData(rhs))
+
+		// L * y = rhs
+		for i := range l.n {
+			diag := l.rowPtr[i+1] - 1
+			sum := x[i]
+			for k := l.rowPtr[i]; k < diag; k++ {
+				sum -= l.values[k] * x[l.colIdx[k]]
+			}
+			x[i] = sum / l.values[diag]
+		}
+
+		// Lᵀ * x = y, columns of Lᵀ are rows of L
+		for i := l.n - 1; i >= 0; i-- {
+			diag := l.rowPtr[i+1] - 1
+			x[i] /= l.values[diag]
+			for k := l.rowPtr[i]; k < diag; k++ {
+				x[l.colIdx[k]] -= l.values[k] * x[i]
+			}
+		}
+
+		for i, v := range x {
+			dst.SetVec(i, v)
+		}
+		return nil
+	}, nil
+}
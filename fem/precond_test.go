@@ -0,0 +1,39 @@
+package fem
+
+import (
+	"testing"
+
+	"gonum.org/v1/gonum/floats"
+)
+
+func TestPreconditionersConverge(t *testing.T) {
+	mesh, bc := newTestProblem(t)
+
+	direct := New(mesh)
+	direct.Method = SolverCholesky
+	expected, err := direct.Solve(testMaterial, bc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedU := expected.Displacements()
+
+	for _, precon := range Preconditioners {
+		t.Run(precon.String(), func(t *testing.T) {
+			f := New(mesh)
+			f.Preconditioner = precon
+			f.Tolerance = 1e-10
+			result, err := f.Solve(testMaterial, bc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if r := result.Stats().Residual; r > f.Tolerance {
+				t.Errorf("residual %g is above tolerance", r)
+			}
+			u := result.Displacements()
+			if d := floats.Distance(u, expectedU, 2) / floats.Norm(expectedU, 2); d > 1e-6 {
+				t.Errorf("relative difference from Cholesky displacements %g", d)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package fem
+
+import (
+	"context"
+	"math"
+
+	"gonum.org/v1/exp/linsolve"
+)
+
+// Stage is a step of the solve
+type Stage int
+
+// Stages of the solve in order of execution
+const (
+	StageStiffness     Stage = iota // Stiffness matrices of elements and global stiffness matrix
+	StageAssembly                   // Forces and constraints
+	StageFactorization              // Factorization of stiffness matrix for direct solver
+	StageSolve                      // Iterations of linear system solver
+	StageStress                     // Strain and stress recovery
+)
+
+var stageNames = [...]string{
+	StageStiffness:     "Element stiffness",
+	StageAssembly:      "Assembly",
+	StageFactorization: "Factorization",
+	StageSolve:         "Solve",
+	StageStress:        "Stress",
+}
+
+func (s Stage) String() string {
+	if s < 0 || int(s) >= len(stageNames) {
+		return "Unknown"
+	}
+	return stageNames[s]
+}
+
+// Progress describes how far the solve went
+type Progress struct {
+	Stage Stage
+	Done  int // Number of elements or matrix rows done, or iterations for StageSolve
+	Total int // Number of elements or matrix rows, or max iterations for StageSolve
+
+	Residual  float64 // Residual norm relative to forces norm, only for StageSolve
+	Tolerance float64 // Relative residual norm required to stop, only for StageSolve
+}
+
+// Fraction returns progress of the current stage in [0, 1], solver progress is estimated by residual decrease as
+// number of iterations is not known beforehand
+func (p Progress) Fraction() float64 {
+	if p.Stage == StageSolve && p.Residual > 0 && p.Tolerance > 0 && p.Tolerance < 1 {
+		return min(max(math.Log(p.Residual)/math.Log(p.Tolerance), 0), 1)
+	}
+	if p.Total <= 0 {
+		return 0
+	}
+	return min(float64(p.Done)/float64(p.Total), 1)
+}
+
+// report sends progress without blocking, updates are dropped while receiver is not ready
+func (f *FEM) report(p Progress) {
+	if f.Progress == nil {
+		return
+	}
+	select {
+	case f.Progress <- p:
+	default:
+	}
+}
+
+// progressMethod wraps linear solver method to report iterations and stop on context cancellation
+type progressMethod struct {
+	linsolve.Method
+
+	ctx           context.Context
+	f             *FEM
+	bNorm         float64
+	tolerance     float64
+	maxIterations int
+	iterations    int
+}
+
+func (m *progressMethod) Iterate(lctx *linsolve.Context) (linsolve.Operation, error) {
+	if err := m.ctx.Err(); err != nil {
+		return linsolve.NoOperation, err
+	}
+
+	op, err := m.Method.Iterate(lctx)
+	if op == linsolve.MajorIteration {
+		m.iterations++
+		m.f.report(Progress{
+			Stage:     StageSolve,
+			Done:      m.iterations,
+			Total:     m.maxIterations,
+			Residual:  lctx.ResidualNorm / m.bNorm,
+			Tolerance: m.tolerance,
+		})
+	}
+	return op, err
+}
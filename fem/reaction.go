@@ -0,0 +1,99 @@
+package fem
+
+import (
+	"cmp"
+	"math"
+	"slices"
+)
+
+// equilibriumFactor is how many times equilibrium error of balanced result may exceed solver tolerance, it is large
+// as residuals of all unknowns add up in the error
+const equilibriumFactor = 1e3
+
+// FaceReaction is a resultant of reactions of constrained unknowns grouped by side number of their sides
+type FaceReaction struct {
+	Side   int        // Side number, -1 for displaced nodes
+	Force  [3]float64 // Sum of reaction forces
+	Moment [3]float64 // Sum of moments of reaction forces about the point
+}
+
+// FaceReactions returns resultant reactions of constrained sides grouped by side number, with moments about the
+// point, unknown shared by sides with different numbers is counted in the smallest one
+func (r *Result) FaceReactions(point [3]float64) []FaceReaction {
+	groups := make(map[int]*FaceReaction)
+	for _, c := range r.constraints {
+		group, ok := groups[c.side]
+		if !ok {
+			group = &FaceReaction{Side: c.side}
+			groups[c.side] = group
+		}
+
+		node, xyz := c.dof/3, c.dof%3
+		var force [3]float64
+		force[xyz] = r.reactions[c.dof]
+		arm := r.mesh.akt[node]
+		for i := range 3 {
+			arm[i] -= point[i]
+		}
+		moment := cross(arm, force)
+		for i := range 3 {
+			group.Force[i] += force[i]
+			group.Moment[i] += moment[i]
+		}
+	}
+
+	reactions := make([]FaceReaction, 0, len(groups))
+	for _, group := range groups {
+		reactions = append(reactions, *group)
+	}
+	slices.SortFunc(reactions, func(a, b FaceReaction) int { return cmp.Compare(a.Side, b.Side) })
+	return reactions
+}
+
+// AppliedLoad returns sum of external forces applied to the body
+func (r *Result) AppliedLoad() [3]float64 {
+	var total [3]float64
+	for i, v := range r.forces {
+		total[i%3] += v
+	}
+	return total
+}
+
+// EquilibriumError returns norm of the sum of reactions and applied load relative to the sum of absolute values of
+// applied forces and reactions, which unlike their resultants doesn't cancel out for balanced loads or when body is
+// only displaced
+func (r *Result) EquilibriumError() float64 {
+	var sum [3]float64
+	var magnitude float64
+	for i, v := range r.forces {
+		sum[i%3] += v
+		magnitude += math.Abs(v)
+	}
+	for i, v := range r.reactions {
+		sum[i%3] += v
+		magnitude += math.Abs(v)
+	}
+
+	if magnitude == 0 {
+		return 0
+	}
+	return norm(sum) / magnitude
+}
+
+// Balanced reports whether equilibrium error is small compared to solver tolerance, otherwise linear system wasn't
+// solved accurately and result shouldn't be trusted
+func (r *Result) Balanced() bool {
+	return r.EquilibriumError() <= equilibriumFactor*r.tolerance
+}
+
+func cross(a, b [3]float64) [3]float64 {
+	return [3]float64{
+		a[1]*b[2] - a[2]*b[1],
+		a[2]*b[0] - a[0]*b[2],
+		a[0]*b[1] - a[1]*b[0],
+	}
+}
+
+func norm(v [3]float64) float64 {
+	return math.Sqrt(v[0]*v[0] + v[1]*v[1] + v[2]*v[2])
+}
@@ -0,0 +1,50 @@
+package fem
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEquilibriumErrorBalancedLoads(t *testing.T) {
+	mesh, bc := newTestProblem(t)
+	clear(bc.Pushed)
+	for _, side := range []int{0, 1} {
+		for _, es := range mesh.FaceSides(side) {
+			bc.Pushed[es] = true
+		}
+	}
+
+	result, err := Solve(mesh, testMaterial, bc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Pressure on opposite faces cancels out, so error can't be relative to applied load
+	if e := result.EquilibriumError(); e > 1e-6 {
+		t.Errorf("equilibrium error %g", e)
+	}
+	if !result.Balanced() {
+		t.Error("result is not balanced")
+	}
+}
+
+func TestBalanced(t *testing.T) {
+	r := &Result{
+		forces:    []float64{0, 0, -1, 0, 0, -1},
+		reactions: []float64{0, 0, 2, 0, 0, 0},
+		tolerance: 1e-8,
+	}
+	if e := r.EquilibriumError(); e != 0 {
+		t.Errorf("equilibrium error %g, expected 0", e)
+	}
+	if !r.Balanced() {
+		t.Error("exact result is not balanced")
+	}
+
+	r.reactions[2] = 1
+	if e := r.EquilibriumError(); math.Abs(e-1.0/3) > 1e-15 {
+		t.Errorf("equilibrium error %g, expected 1/3", e)
+	}
+	if r.Balanced() {
+		t.Error("result missing half of reaction is balanced")
+	}
+}
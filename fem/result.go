@@ -0,0 +1,123 @@
+package fem
+
+import "time"
+
+// Result is a solution of the deformation problem
+type Result struct {
+	mesh *Mesh
+	u    []float64 // Displacements, npq * 3 (x, y, z)
+
+	strain [][27]Tensor // Strain at Gauss points, npq * 27
+	stress [][27]Tensor // Stress at Gauss points, npq * 27
+
+	nodalStress []Tensor // Stress at vertices averaged over elements, npq
+
+	forces      []float64       // External forces applied to nodes, npq * 3 (x, y, z)
+	reactions   []float64       // Reactions of constrained unknowns, zero for others, npq * 3 (x, y, z)
+	constraints []dofConstraint // Fixed and displaced unknowns
+	tolerance   float64         // Relative residual tolerance of the solver
+
+	stats SolveStats
+}
+
+// SolveStats describes how linear system of the problem was solved
+type SolveStats struct {
+	Method         SolverMethod
+	Constraints    ConstraintMethod // Method used to impose constraints, penalty is replaced for CG with displacements
+	Preconditioner Preconditioner   // Preconditioner of conjugate gradient method
+	Iterations     int              // Number of solver iterations, zero for direct solver
+	MulVec         int              // Number of stiffness matrix multiplications
+	PreconSolve    int              // Number of preconditioner solves
+	Residual       float64          // Final residual norm relative to forces norm
+	Duration       time.Duration    // Time spent building preconditioner and iterating or factorizing and solving
+
+	Factorized     bool // Factorization was computed by this solve rather than reused
+	FactorNonzeros int  // Number of nonzeros in factorization of direct solver
+}
+
+// Mesh returns mesh that was solved
+func (r *Result) Mesh() *Mesh {
+	return r.mesh
+}
+
+// Stats returns statistics of the linear system solve
+func (r *Result) Stats() SolveStats {
+	return r.stats
+}
+
+// Displacements returns displacements of all nodes, npq * 3 (x, y, z), must not be modified
+func (r *Result) Displacements() []float64 {
+	return r.u
+}
+
+// Displacement returns displacement of the node
+func (r *Result) Displacement(node int) [3]float64 {
+	return [3]float64{r.u[3*node], r.u[3*node+1], r.u[3*node+2]}
+}
+
+// Reactions returns forces applied by constraints to all nodes, zero for unconstrained components,
+// npq * 3 (x, y, z), must not be modified
+func (r *Result) Reactions() []float64 {
+	return r.reactions
+}
+
+// Reaction returns force applied by constraints to the node
+func (r *Result) Reaction(node int) [3]float64 {
+	return [3]float64{r.reactions[3*node], r.reactions[3*node+1], r.reactions[3*node+2]}
+}
+
+// DisplacementReaction returns total force needed to impose prescribed displacements
+func (r *Result) DisplacementReaction() [3]float64 {
+	var total [3]float64
+	for _, c := range r.constraints {
+		if c.displaced {
+			total[c.dof%3] += r.reactions[c.dof]
+		}
+	}
+	return total
+}
+
+// DeformedNodes returns coords of all nodes after deformation
+func (r *Result) DeformedNodes() [][3]float64 {
+	return r.ScaledNodes(1)
+}
+
+// ScaledNodes returns coords of all nodes with displacements multiplied by scale, used to magnify small deformations
+func (r *Result) ScaledNodes(scale float64) [][3]float64 {
+	dAKT := make([][3]float64, len(r.mesh.akt))
+	for i, point := range r.mesh.akt {
+		u := r.Displacement(i)
+		dAKT[i] = [3]float64{point[0] + scale*u[0], point[1] + scale*u[1], point[2] + scale*u[2]}
+	}
+	return dAKT
+}
+
+// GaussStrain returns strain of the element at Gauss point, points are ordered by z, then y, then x local coords
+func (r *Result) GaussStrain(element, point int) Tensor {
+	return r.strain[element][point]
+}
+
+// GaussStress returns stress of the element at Gauss point, points are ordered by z, then y, then x local coords
+func (r *Result) GaussStress(element, point int) Tensor {
+	return r.stress[element][point]
+}
+
+// Strains returns strain at all Gauss points of each element, must not be modified
+func (r *Result) Strains() [][27]Tensor {
+	return r.strain
+}
+
+// Stresses returns stress at all Gauss points of each element, must not be modified
+func (r *Result) Stresses() [][27]Tensor {
+	return r.stress
+}
+
+// NodalStress returns stress at the node extrapolated from Gauss points and averaged over elements that share it
+func (r *Result) NodalStress(node int) Tensor {
+	return r.nodalStress[node]
+}
+
+// NodalStresses returns stress at all nodes, must not be modified
+func (r *Result) NodalStresses() []Tensor {
+	return r.nodalStress
+}
@@ -0,0 +1,364 @@
+package fem
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+)
+
+// ScenarioVersion is the version of scenario format written by this package
+const ScenarioVersion = 9
+
+// Scenario is a complete setup of the simulation that can be saved and loaded
+type Scenario struct {
+	Version  int           `json:"version"`
+	Size     [3]float64    `json:"size"`
+	Split    [3]int        `json:"split"`
+	Material Material      `json:"material"` // Material of elements not listed in ElementMaterials
+	Pressure float64       `json:"pressure"`
+	Fixed    []FixedSide   `json:"fixed"`
+	Pushed   []ElementSide `json:"pushed"`
+
+	PressureField  *Expression     `json:"pressure_field,omitempty"` // Added in version 7, overrides pressure
+	Tractions      []TractionSide  `json:"tractions,omitempty"`
+	BodyForce      *BodyForce      `json:"body_force,omitempty"` // Added in version 5, field function isn't saved
+	NodalLoads     []NodalLoad     `json:"nodal_loads,omitempty"`
+	Displaced      []DisplacedSide `json:"displaced,omitempty"`
+	DisplacedNodes []DisplacedNode `json:"displaced_nodes,omitempty"`
+
+	// Added in version 8, materials are numbered from 1 as Material has number 0
+	Materials        []Material        `json:"materials,omitempty"`
+	ElementMaterials []ElementMaterial `json:"element_materials,omitempty"`
+}
+
+// ElementMaterial is an element made of material other than the main one, added in version 8
+type ElementMaterial struct {
+	Element  int `json:"element"`
+	Material int `json:"material"` // Number of material, 1 for the first one of Materials
+}
+
+// TractionSide is a side with applied traction, added in version 4
+type TractionSide struct {
+	ElementSide
+	Traction
+}
+
+// NodalLoad is a concentrated force applied to a node, added in version 6
+type NodalLoad struct {
+	Node  int        `json:"node"`
+	Force [3]float64 `json:"force"`
+}
+
+// DisplacedSide is a side with prescribed displacement, added in version 3
+type DisplacedSide struct {
+	ElementSide
+	Displacement
+}
+
+// DisplacedNode is a node with prescribed displacement, added in version 3
+type DisplacedNode struct {
+	Node int `json:"node"`
+	Displacement
+}
+
+// displacement returns displacement with all axes if they are not set
+func displacement(d Displacement) Displacement {
+	if d.Axes == 0 {
+		d.Axes = AxesAll
+	}
+	return d
+}
+
+// FixedSide is a side with fixed displacement components, version 1 has no axes meaning all of them are fixed
+type FixedSide struct {
+	ElementSide
+	Axes Axes `json:"axes,omitempty"`
+}
+
+// axes returns fixed axes of the side, all axes if they are not set
+func (fs FixedSide) axes() Axes {
+	if fs.Axes == 0 {
+		return AxesAll
+	}
+	return fs.Axes
+}
+
+// NewScenario captures current setup of the simulation
+func NewScenario(mesh *Mesh, material Material, bc *BoundaryConditions) *Scenario {
+	return NewMaterialsScenario(mesh, UniformMaterials(material, len(mesh.elements)), bc)
+}
+
+// NewMaterialsScenario captures current setup of the simulation of body made of few materials, the first material
+// of the table is the main one
+func NewMaterialsScenario(mesh *Mesh, materials *Materials, bc *BoundaryConditions) *Scenario {
+	var elementMaterials []ElementMaterial
+	for i, index := range materials.Elements {
+		if index != 0 {
+			elementMaterials = append(elementMaterials, ElementMaterial{Element: i, Material: index})
+		}
+	}
+
+	return &Scenario{
+		Version:  ScenarioVersion,
+		Size:     mesh.size,
+		Split:    mesh.split,
+		Material: materials.Table[0],
+		Pressure: bc.Pressure,
+		Fixed:    fixedSides(bc.Fixed),
+		Pushed:   selectedSides(bc.Pushed),
+
+		PressureField:  bc.PressureField,
+		Tractions:      tractionSides(bc.Tractions),
+		BodyForce:      bodyForce(bc.BodyForce),
+		NodalLoads:     nodalLoads(bc.NodalLoads),
+		Displaced:      displacedSides(bc.Displaced),
+		DisplacedNodes: displacedNodes(bc.DisplacedNodes),
+
+		Materials:        slices.Clone(materials.Table[1:]),
+		ElementMaterials: elementMaterials,
+	}
+}
+
+// selectedSides returns sorted sides that are set
+func selectedSides(sides map[ElementSide]bool) []ElementSide {
+	selected := slices.Collect(maps.Keys(sides))
+	selected = slices.DeleteFunc(selected, func(es ElementSide) bool { return !sides[es] })
+	slices.SortFunc(selected, compareSides)
+	return selected
+}
+
+// fixedSides returns sorted sides that are fixed along at least one axis
+func fixedSides(sides map[ElementSide]Axes) []FixedSide {
+	fixed := make([]FixedSide, 0, len(sides))
+	for es, axes := range sides {
+		if axes != 0 {
+			fixed = append(fixed, FixedSide{ElementSide: es, Axes: axes})
+		}
+	}
+	slices.SortFunc(fixed, func(a, b FixedSide) int { return compareSides(a.ElementSide, b.ElementSide) })
+	return fixed
+}
+
+// tractionSides returns sorted sides with traction
+func tractionSides(sides map[ElementSide]Traction) []TractionSide {
+	var tractions []TractionSide
+	for _, es := range slices.SortedFunc(maps.Keys(sides), compareSides) {
+		tractions = append(tractions, TractionSide{ElementSide: es, Traction: sides[es]})
+	}
+	return tractions
+}
+
+// bodyForce returns inertial part of body force, nil if there is none
+func bodyForce(b BodyForce) *BodyForce {
+	if b.Density == 0 || b.Acceleration == [3]float64{} {
+		return nil
+	}
+	return &BodyForce{Density: b.Density, Acceleration: b.Acceleration}
+}
+
+// nodalLoads returns sorted loaded nodes
+func nodalLoads(loads map[int][3]float64) []NodalLoad {
+	var nodal []NodalLoad
+	for _, node := range slices.Sorted(maps.Keys(loads)) {
+		nodal = append(nodal, NodalLoad{Node: node, Force: loads[node]})
+	}
+	return nodal
+}
+
+// displacedSides returns sorted sides that have displacement along at least one axis
+func displacedSides(sides map[ElementSide]Displacement) []DisplacedSide {
+	var displaced []DisplacedSide
+	for _, es := range slices.SortedFunc(maps.Keys(sides), compareSides) {
+		if sides[es].Axes != 0 {
+			displaced = append(displaced, DisplacedSide{ElementSide: es, Displacement: sides[es]})
+		}
+	}
+	return displaced
+}
+
+// displacedNodes returns sorted nodes that have displacement along at least one axis
+func displacedNodes(nodes map[int]Displacement) []DisplacedNode {
+	var displaced []DisplacedNode
+	for _, node := range slices.Sorted(maps.Keys(nodes)) {
+		if nodes[node].Axes != 0 {
+			displaced = append(displaced, DisplacedNode{Node: node, Displacement: nodes[node]})
+		}
+	}
+	return displaced
+}
+
+// ReadScenario reads and validates JSON encoded scenario
+func ReadScenario(r io.Reader) (*Scenario, error) {
+	var s Scenario
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, fmt.Errorf("decode scenario: %w", err)
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// LoadScenario reads and validates scenario from the file
+func LoadScenario(path string) (*Scenario, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open scenario: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	return ReadScenario(file)
+}
+
+// Write writes JSON encoded scenario
+func (s *Scenario) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(s); err != nil {
+		return fmt.Errorf("encode scenario: %w", err)
+	}
+	return nil
+}
+
+// Save writes scenario to the file
+func (s *Scenario) Save(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create scenario: %w", err)
+	}
+
+	if err = s.Write(file); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Validate checks that scenario is supported and all sides fit the mesh
+func (s *Scenario) Validate() error {
+	if s.Version < 1 || s.Version > ScenarioVersion {
+		return fmt.Errorf("unsupported scenario version %d", s.Version)
+	}
+	for i := range 3 {
+		if s.Size[i] <= 0 {
+			return fmt.Errorf("invalid body size %v", s.Size)
+		}
+		if s.Split[i] < 1 {
+			return fmt.Errorf("invalid body split %v", s.Split)
+		}
+	}
+	if err := s.Material.Validate(); err != nil {
+		return err
+	}
+	for i, material := range s.Materials {
+		if err := material.Validate(); err != nil {
+			return fmt.Errorf("material %d: %w", i+1, err)
+		}
+	}
+
+	elements := s.Split[0] * s.Split[1] * s.Split[2]
+	for _, em := range s.ElementMaterials {
+		if em.Element < 0 || em.Element >= elements {
+			return fmt.Errorf("invalid element %d with material", em.Element)
+		}
+		if em.Material < 0 || em.Material > len(s.Materials) {
+			return fmt.Errorf("invalid material %d of element %d", em.Material, em.Element)
+		}
+	}
+	for _, fs := range s.Fixed {
+		if !fs.valid(elements) {
+			return &SideError{ElementSide: fs.ElementSide}
+		}
+		if fs.Axes&^AxesAll != 0 {
+			return fmt.Errorf("invalid fixed axes %08b of side %d of element %d", fs.Axes, fs.Side, fs.Element)
+		}
+	}
+	for _, es := range s.Pushed {
+		if !es.valid(elements) {
+			return &SideError{ElementSide: es}
+		}
+	}
+	for _, ts := range s.Tractions {
+		if !ts.valid(elements) {
+			return &SideError{ElementSide: ts.ElementSide}
+		}
+	}
+	if s.BodyForce != nil && s.BodyForce.Density < 0 {
+		return fmt.Errorf("invalid density %g", s.BodyForce.Density)
+	}
+	for _, ds := range s.Displaced {
+		if !ds.valid(elements) {
+			return &SideError{ElementSide: ds.ElementSide}
+		}
+		if ds.Axes&^AxesAll != 0 {
+			return fmt.Errorf("invalid displaced axes %08b of side %d of element %d", ds.Axes, ds.Side, ds.Element)
+		}
+	}
+	nodes := nodeCount(s.Split)
+	for _, nl := range s.NodalLoads {
+		if nl.Node < 0 || nl.Node >= nodes {
+			return fmt.Errorf("invalid loaded node %d", nl.Node)
+		}
+	}
+	for _, dn := range s.DisplacedNodes {
+		if dn.Node < 0 || dn.Node >= nodes {
+			return fmt.Errorf("invalid displaced node %d", dn.Node)
+		}
+		if dn.Axes&^AxesAll != 0 {
+			return fmt.Errorf("invalid displaced axes %08b of node %d", dn.Axes, dn.Node)
+		}
+	}
+	return nil
+}
+
+// Mesh builds mesh of the scenario
+func (s *Scenario) Mesh() (*Mesh, error) {
+	return NewMesh(s.Size, s.Split)
+}
+
+// MaterialsTable returns materials of the scenario with the main material first
+func (s *Scenario) MaterialsTable() *Materials {
+	materials := UniformMaterials(s.Material, s.Split[0]*s.Split[1]*s.Split[2])
+	materials.Table = append(materials.Table, s.Materials...)
+	for _, em := range s.ElementMaterials {
+		materials.Elements[em.Element] = em.Material
+	}
+	return materials
+}
+
+// BoundaryConditions returns boundary conditions of the scenario
+func (s *Scenario) BoundaryConditions() *BoundaryConditions {
+	bc := NewBoundaryConditions()
+	bc.Pressure = s.Pressure
+	bc.PressureField = s.PressureField
+	for _, fs := range s.Fixed {
+		bc.Fixed[fs.ElementSide] |= fs.axes()
+	}
+	for _, es := range s.Pushed {
+		bc.Pushed[es] = true
+	}
+	for _, ts := range s.Tractions {
+		bc.Tractions[ts.ElementSide] = ts.Traction
+	}
+	if s.BodyForce != nil {
+		bc.BodyForce = *s.BodyForce
+	}
+	for _, nl := range s.NodalLoads {
+		// Loads of the same node add up
+		force := bc.NodalLoads[nl.Node]
+		for i := range 3 {
+			force[i] += nl.Force[i]
+		}
+		bc.NodalLoads[nl.Node] = force
+	}
+	for _, ds := range s.Displaced {
+		bc.Displaced[ds.ElementSide] = displacement(ds.Displacement)
+	}
+	for _, dn := range s.DisplacedNodes {
+		bc.DisplacedNodes[dn.Node] = displacement(dn.Displacement)
+	}
+	return bc
+}
@@ -0,0 +1,169 @@
+package fem
+
+import (
+	"bytes"
+	"maps"
+	"reflect"
+	"testing"
+)
+
+// newTestScenario returns scenario using every feature of the current version
+func newTestScenario(t *testing.T) *Scenario {
+	t.Helper()
+
+	mesh, bc := newTestProblem(t)
+	bc.Fixed[ElementSide{Element: 0, Side: 0}] = AxisX
+	bc.Tractions[ElementSide{Element: 2, Side: 1}] = Traction{Vector: [3]float64{0, 0.5, 0}, Local: true}
+	bc.BodyForce = BodyForce{Density: 1.5, Acceleration: [3]float64{0, 0, -9.81}}
+	bc.NodalLoads[3] = [3]float64{0, 0, -1}
+	bc.Displaced[ElementSide{Element: 26, Side: 5}] = Displacement{Axes: AxisZ, Value: [3]float64{0, 0, 0.1}}
+	bc.DisplacedNodes[5] = Displacement{Axes: AxesAll, Value: [3]float64{0.1, 0, 0}}
+
+	var err error
+	bc.PressureField, err = ParseExpression("2 + 0.5*z")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	materials := UniformMaterials(testMaterial, len(mesh.elements))
+	materials.Table = append(materials.Table,
+		Material{YoungsModulus: 1, PoissonRatio: 0.25},
+		Material{Type: MaterialOrthotropic, Orthotropic: Orthotropic{
+			E1: 5, E2: 10, E3: 2, Nu12: 0.2, Nu13: 0.4, Nu23: 0.1, G12: 1, G13: 1, G23: 1,
+		}},
+	)
+	materials.Elements[4], materials.Elements[13] = 1, 2
+	return NewMaterialsScenario(mesh, materials, bc)
+}
+
+func TestScenarioRoundTrip(t *testing.T) {
+	scenario := newTestScenario(t)
+
+	var written bytes.Buffer
+	if err := scenario.Write(&written); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := ReadScenario(bytes.NewReader(written.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var rewritten bytes.Buffer
+	if err = loaded.Write(&rewritten); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(written.Bytes(), rewritten.Bytes()) {
+		t.Errorf("loaded scenario is written differently:\n%s\nexpected:\n%s", rewritten.Bytes(), written.Bytes())
+	}
+
+	// Expression is compared by its text as parsed one holds a function
+	expected, actual := scenario.BoundaryConditions(), loaded.BoundaryConditions()
+	if actual.PressureField.String() != expected.PressureField.String() {
+		t.Errorf("pressure field %s, expected %s", actual.PressureField, expected.PressureField)
+	}
+	expected.PressureField, actual.PressureField = nil, nil
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("boundary conditions %+v, expected %+v", actual, expected)
+	}
+	if !reflect.DeepEqual(loaded.MaterialsTable(), scenario.MaterialsTable()) {
+		t.Error("materials differ")
+	}
+}
+
+func TestScenarioValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(s *Scenario)
+	}{
+		{"version 0", func(s *Scenario) { s.Version = 0 }},
+		{"future version", func(s *Scenario) { s.Version = ScenarioVersion + 1 }},
+		{"zero size", func(s *Scenario) { s.Size[1] = 0 }},
+		{"zero split", func(s *Scenario) { s.Split[2] = 0 }},
+		{"invalid material", func(s *Scenario) { s.Material.PoissonRatio = 0.5 }},
+		{"invalid extra material", func(s *Scenario) { s.Materials[0].YoungsModulus = -1 }},
+		{"unknown material", func(s *Scenario) { s.ElementMaterials[0].Material = len(s.Materials) + 1 }},
+		{"negative material", func(s *Scenario) { s.ElementMaterials[0].Material = -1 }},
+		{"element of material", func(s *Scenario) { s.ElementMaterials[0].Element = 27 }},
+		{"fixed side", func(s *Scenario) { s.Fixed[0].Side = 6 }},
+		{"fixed axes", func(s *Scenario) { s.Fixed[0].Axes = 8 }},
+		{"pushed element", func(s *Scenario) { s.Pushed[0].Element = -1 }},
+		{"loaded node", func(s *Scenario) { s.NodalLoads[0].Node = nodeCount(s.Split) }},
+		{"displaced node", func(s *Scenario) { s.DisplacedNodes[0].Node = -1 }},
+		{"negative density", func(s *Scenario) { s.BodyForce.Density = -1 }},
+	}
+
+	if err := newTestScenario(t).Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scenario := newTestScenario(t)
+			tt.modify(scenario)
+			if err := scenario.Validate(); err == nil {
+				t.Error("invalid scenario is accepted")
+			}
+		})
+	}
+}
+
+func TestScenarioOldVersions(t *testing.T) {
+	// Files are written by the package at versions 1 and 8, before fixed axes and before material types
+	tests := []struct {
+		file      string
+		version   int
+		fixed     map[ElementSide]Axes
+		materials []int // Material of elements with material other than the main one
+	}{
+		{
+			file:    "testdata/scenario_v1.json",
+			version: 1,
+			fixed:   map[ElementSide]Axes{{Element: 0, Side: 4}: AxesAll, {Element: 1, Side: 4}: AxesAll},
+		},
+		{
+			file:      "testdata/scenario_v8.json",
+			version:   8,
+			fixed:     map[ElementSide]Axes{{Element: 0, Side: 4}: AxesAll, {Element: 1, Side: 0}: AxisX},
+			materials: []int{4, 5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			scenario, err := LoadScenario(tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if scenario.Version != tt.version {
+				t.Errorf("version %d, expected %d", scenario.Version, tt.version)
+			}
+
+			bc := scenario.BoundaryConditions()
+			if !maps.Equal(bc.Fixed, tt.fixed) {
+				t.Errorf("fixed sides %v, expected %v", bc.Fixed, tt.fixed)
+			}
+			if !bc.Pushed[ElementSide{Element: 7, Side: 5}] || bc.Pressure != 2 {
+				t.Errorf("pushed sides %v with pressure %g", bc.Pushed, bc.Pressure)
+			}
+
+			materials := scenario.MaterialsTable()
+			for i, m := range materials.Table {
+				if m.Type != MaterialIsotropic {
+					t.Errorf("material %d is %s", i, m.Type)
+				}
+			}
+			for _, element := range tt.materials {
+				if materials.Elements[element] != 1 {
+					t.Errorf("element %d has material %d, expected 1", element, materials.Elements[element])
+				}
+			}
+
+			mesh, err := scenario.Mesh()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err = New(mesh).SolveMaterials(t.Context(), materials, bc); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package fem
+
+import (
+	"slices"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// sparseMatrix is a square matrix in compressed sparse row (CSR) format
+type sparseMatrix struct {
+	n      int       // Matrix size
+	rowPtr []int     // Start of each row in colIdx and values, n + 1
+	colIdx []int     // Column indexes of stored values, sorted within each row, nnz
+	values []float64 // Stored values, nnz
+}
+
+// newStiffnessPattern creates zero stiffness matrix with 3 (x, y, z) rows and columns per node, where entries are
+// stored only for nodes sharing at least one element
+func newStiffnessPattern(nodes int, nt [][20]int) *sparseMatrix {
+	neighbors := make([][]int, nodes)
+	for _, element := range nt {
+		for _, i := range element {
+			neighbors[i] = append(neighbors[i], element[:]...)
+		}
+	}
+
+	nnz := 0
+	for i := range neighbors {
+		slices.Sort(neighbors[i])
+		neighbors[i] = slices.Compact(neighbors[i])
+		nnz += 9 * len(neighbors[i])
+	}
+
+	m := &sparseMatrix{
+		n:      3 * nodes,
+		rowPtr: make([]int, 3*nodes+1),
+		colIdx: make([]int, 0, nnz),
+		values: make([]float64, nnz),
+	}
+	for i, nodeNeighbors := range neighbors {
+		for xyz := range 3 {
+			for _, j := range nodeNeighbors {
+				m.colIdx = append(m.colIdx, 3*j+0, 3*j+1, 3*j+2)
+			}
+			m.rowPtr[3*i+xyz+1] = len(m.colIdx)
+		}
+	}
+
+	return m
+}
+
+// clone returns copy of the matrix, sparsity pattern is shared between copies
+func (m *sparseMatrix) clone() *sparseMatrix {
+	return &sparseMatrix{
+		n:      m.n,
+		rowPtr: m.rowPtr,
+		colIdx: m.colIdx,
+		values: slices.Clone(m.values),
+	}
+}
+
+// index returns position of (i, j) entry in values or -1 if it's not stored
+func (m *sparseMatrix) index(i, j int) int {
+	start, end := m.rowPtr[i], m.rowPtr[i+1]
+	k, found := slices.BinarySearch(m.colIdx[start:end], j)
+	if !found {
+		return -1
+	}
+	return start + k
+}
+
+func (m *sparseMatrix) Dims() (int, int) {
+	return m.n, m.n
+}
+
+func (m *sparseMatrix) At(i, j int) float64 {
+	k := m.index(i, j)
+	if k < 0 {
+		return 0
+	}
+	return m.values[k]
+}
+
+func (m *sparseMatrix) set(i, j int, v float64) {
+	k := m.index(i, j)
+	if k < 0 {
+		panic("set value outside of sparse pattern")
+	}
+	m.values[k] = v
+}
+
+func (m *sparseMatrix) MulVecTo(dst *mat.VecDense, trans bool, x mat.Vector) {
+	xVec, ok := x.(*mat.VecDense)
+	if !ok || xVec.RawVector().Inc != 1 {
+		xVec = mat.VecDenseCopyOf(x)
+	}
+	xs := xVec.RawVector().Data
+
+	// Stiffness matrix is symmetric, but transposed product is still computed properly for other matrices
+	if trans {
+		sums := make([]float64, m.n)
+		for i := range m.n {
+			for k := m.rowPtr[i]; k < m.rowPtr[i+1]; k++ {
+				sums[m.colIdx[k]] += m.values[k] * xs[i]
+			}
+		}
+		for i, sum := range sums {
+			dst.SetVec(i, sum)
+		}
+		return
+	}
+
+	for i := range m.n {
+		var sum float64
+		for k := m.rowPtr[i]; k < m.rowPtr[i+1]; k++ {
+			sum += m.values[k] * xs[m.colIdx[k]]
+		}
+		dst.SetVec(i, sum)
+	}
+}
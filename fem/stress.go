@@ -0,0 +1,133 @@
+package fem
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Tensor is a symmetric 3x3 tensor in Voigt notation: xx, yy, zz, xy, yz, zx, shear components of strain are
+// engineering (doubled) values
+type Tensor [6]float64
+
+// calculateStrainStress computes strain and stress at Gauss points of each element from displacements, derivatives of
+// approximation functions are computed again as they are not kept after assembly
+func (f *FEM) calculateStrainStress(materials *Materials) ([][27]Tensor, [][27]Tensor, error) {
+	strain := make([][27]Tensor, len(f.mesh.nt))
+	stress := make([][27]Tensor, len(f.mesh.nt))
+
+	errs := make([]error, len(f.mesh.nt))
+	parallelFor(len(f.mesh.nt), f.Workers, func(i int) {
+		dfixyz, _, err := f.elementDerivatives(i)
+		if err != nil {
+			errs[i] = err
+			return
+		}
+
+		d := materials.element(i).elasticity()
+		for j, dfi := range dfixyz {
+			var eps Tensor
+			for k, node := range f.mesh.nt[i] {
+				ux, uy, uz := f.u[3*node], f.u[3*node+1], f.u[3*node+2]
+				eps[0] += dfi[k][0] * ux
+				eps[1] += dfi[k][1] * uy
+				eps[2] += dfi[k][2] * uz
+				eps[3] += dfi[k][1]*ux + dfi[k][0]*uy
+				eps[4] += dfi[k][2]*uy + dfi[k][1]*uz
+				eps[5] += dfi[k][0]*uz + dfi[k][2]*ux
+			}
+			strain[i][j] = eps
+
+			stress[i][j] = stressOf(d, eps)
+		}
+	})
+	for _, err := range errs {
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return strain, stress, nil
+}
+
+// stressOf returns stress of the strain for constitutive matrix d
+func stressOf(d [6][6]float64, strain Tensor) Tensor {
+	var stress Tensor
+	for i := range 6 {
+		for j := range 6 {
+			stress[i] += d[i][j] * strain[j]
+		}
+	}
+	return stress
+}
+
+// calculateNodalStress extrapolates stress from Gauss points to element vertices and averages it over elements that
+// share the vertex
+func (f *FEM) calculateNodalStress(stress [][27]Tensor) []Tensor {
+	nodal := make([]Tensor, len(f.mesh.akt))
+	count := make([]int, len(f.mesh.akt))
+
+	for i, nodes := range f.mesh.nt {
+		for j, node := range nodes {
+			for k, t := range stress[i] {
+				for c := range t {
+					nodal[node][c] += gaussToNode[j][k] * t[c]
+				}
+			}
+			count[node]++
+		}
+	}
+
+	for i := range nodal {
+		if count[i] == 0 {
+			continue
+		}
+		for c := range nodal[i] {
+			nodal[i][c] /= float64(count[i])
+		}
+	}
+	return nodal
+}
+
+// VonMises returns von Mises equivalent stress
+func (t Tensor) VonMises() float64 {
+	return math.Sqrt(0.5*((t[0]-t[1])*(t[0]-t[1])+(t[1]-t[2])*(t[1]-t[2])+(t[2]-t[0])*(t[2]-t[0])) +
+		3*(t[3]*t[3]+t[4]*t[4]+t[5]*t[5]))
+}
+
+// Pressure returns hydrostatic pressure, positive in compression
+func (t Tensor) Pressure() float64 {
+	return -(t[0] + t[1] + t[2]) / 3
+}
+
+// Tresca returns Tresca equivalent stress, difference between max and min principal stresses
+func (t Tensor) Tresca() float64 {
+	values, _ := t.Principal()
+	return values[0] - values[2]
+}
+
+// Principal returns principal stresses in descending order and their unit directions
+func (t Tensor) Principal() ([3]float64, [3][3]float64) {
+	var eigen mat.EigenSym
+	ok := eigen.Factorize(mat.NewSymDense(3, []float64{
+		t[0], t[3], t[5],
+		t[3], t[1], t[4],
+		t[5], t[4], t[2],
+	}), true)
+	if !ok {
+		return [3]float64{}, [3][3]float64{}
+	}
+
+	var vectors mat.Dense
+	eigen.VectorsTo(&vectors)
+	rawValues := eigen.Values(nil)
+
+	// Eigenvalues are in ascending order
+	var values [3]float64
+	var directions [3][3]float64
+	for i := range 3 {
+		values[i] = rawValues[2-i]
+		directions[i] = [3]float64{vectors.At(0, 2-i), vectors.At(1, 2-i), vectors.At(2, 2-i)}
+	}
+	return values, directions
+}
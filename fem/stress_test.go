@@ -0,0 +1,88 @@
+package fem
+
+import (
+	"math"
+	"testing"
+)
+
+func TestUniaxialTension(t *testing.T) {
+	mesh, err := NewMesh([3]float64{4, 2, 1}, [3]int{3, 2, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Rollers on three faces let the body contract freely, right face is pulled along x
+	const p = 2.0
+	bc := NewBoundaryConditions()
+	for side, axes := range map[int]Axes{0: AxisX, 2: AxisY, 4: AxisZ} {
+		for _, es := range mesh.FaceSides(side) {
+			bc.Fixed[es] = axes
+		}
+	}
+	for _, es := range mesh.FaceSides(1) {
+		bc.Tractions[es] = Traction{Vector: [3]float64{p, 0, 0}}
+	}
+
+	f := New(mesh)
+	f.Method = SolverCholesky
+	f.Constraints = ConstraintElimination
+	result, err := f.Solve(testMaterial, bc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const eps = 1e-9
+	e, nu := testMaterial.YoungsModulus, testMaterial.PoissonRatio
+	expectedStrain := Tensor{p / e, -nu * p / e, -nu * p / e}
+	expectedStress := Tensor{p}
+	for i, stresses := range result.Stresses() {
+		for j, stress := range stresses {
+			strain := result.GaussStrain(i, j)
+			for c := range 6 {
+				if math.Abs(stress[c]-expectedStress[c]) > eps || math.Abs(strain[c]-expectedStrain[c]) > eps {
+					t.Fatalf("element %d, point %d: stress %v, strain %v", i, j, stress, strain)
+				}
+			}
+		}
+	}
+
+	for node, stress := range result.NodalStresses() {
+		if math.Abs(stress.VonMises()-p) > eps || math.Abs(stress.Tresca()-p) > eps {
+			t.Fatalf("node %d: von Mises %g, Tresca %g, expected %g", node, stress.VonMises(), stress.Tresca(), p)
+		}
+		values, directions := stress.Principal()
+		if math.Abs(values[0]-p) > eps || math.Abs(values[1]) > eps || math.Abs(values[2]) > eps {
+			t.Fatalf("node %d: principal stresses %v", node, values)
+		}
+		if math.Abs(math.Abs(directions[0][0])-1) > eps {
+			t.Fatalf("node %d: direction of the largest principal stress %v", node, directions[0])
+		}
+	}
+}
+
+func TestTensorInvariants(t *testing.T) {
+	// Pure shear in xy plane has principal stresses s, 0 and -s along diagonals
+	const s = 3.0
+	shear := Tensor{0, 0, 0, s, 0, 0}
+	values, directions := shear.Principal()
+	if math.Abs(values[0]-s) > 1e-12 || math.Abs(values[1]) > 1e-12 || math.Abs(values[2]+s) > 1e-12 {
+		t.Errorf("principal stresses %v", values)
+	}
+	if d := directions[0]; math.Abs(math.Abs(d[0])-math.Sqrt2/2) > 1e-12 || math.Abs(d[0]-d[1]) > 1e-12 {
+		t.Errorf("direction of the largest principal stress %v", d)
+	}
+	if v := shear.VonMises(); math.Abs(v-math.Sqrt(3)*s) > 1e-12 {
+		t.Errorf("von Mises %g, expected %g", v, math.Sqrt(3)*s)
+	}
+	if v := shear.Tresca(); math.Abs(v-2*s) > 1e-12 {
+		t.Errorf("Tresca %g, expected %g", v, 2*s)
+	}
+
+	hydrostatic := Tensor{-1, -1, -1}
+	if v := hydrostatic.Pressure(); v != 1 {
+		t.Errorf("pressure %g, expected 1", v)
+	}
+	if v := hydrostatic.VonMises(); v != 0 {
+		t.Errorf("von Mises of hydrostatic stress %g", v)
+	}
+}
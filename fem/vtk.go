@@ -0,0 +1,249 @@
+package fem
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// vtkQuadraticHexahedron is VTK cell type of 20-node hexahedron
+const vtkQuadraticHexahedron = 25
+
+// vtkNodeOrder maps VTK node order of quadratic hexahedron to local element node indexes, corners are ordered the
+// same way, but middle points of vertical edges go after middle points of top edges in VTK
+var vtkNodeOrder = [20]int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 16, 17, 18, 19, 12, 13, 14, 15}
+
+// vtkField is a named array of values with fixed number of components per point or cell
+type vtkField struct {
+	name       string
+	components int
+	values     []float64
+}
+
+// vtkPointFields returns fields defined at mesh nodes
+func vtkPointFields(r *Result) []vtkField {
+	fields := []vtkField{
+		{name: "displacement", components: 3, values: r.u},
+	}
+	if r.nodalStress == nil {
+		return fields
+	}
+
+	n := len(r.nodalStress)
+	stress := make([]float64, 0, 6*n)
+	vonMises := make([]float64, 0, n)
+	pressure := make([]float64, 0, n)
+	tresca := make([]float64, 0, n)
+	principal := make([]float64, 0, 3*n)
+	var directions [3][]float64
+	for _, t := range r.nodalStress {
+		values, dirs := t.Principal()
+
+		stress = append(stress, t[:]...)
+		vonMises = append(vonMises, t.VonMises())
+		pressure = append(pressure, t.Pressure())
+		tresca = append(tresca, values[0]-values[2])
+		principal = append(principal, values[:]...)
+		for i, dir := range dirs {
+			directions[i] = append(directions[i], dir[:]...)
+		}
+	}
+
+	return append(fields,
+		vtkField{name: "stress", components: 6, values: stress},
+		vtkField{name: "von_mises", components: 1, values: vonMises},
+		vtkField{name: "pressure", components: 1, values: pressure},
+		vtkField{name: "tresca", components: 1, values: tresca},
+		vtkField{name: "principal_stress", components: 3, values: principal},
+		vtkField{name: "principal_direction_1", components: 3, values: directions[0]},
+		vtkField{name: "principal_direction_2", components: 3, values: directions[1]},
+		vtkField{name: "principal_direction_3", components: 3, values: directions[2]},
+	)
+}
+
+// vtkCellFields returns fields defined per element
+func vtkCellFields(r *Result) []vtkField {
+	if r.stress == nil {
+		return nil
+	}
+	return []vtkField{
+		{name: "strain", components: 6, values: averageGaussPoints(r.strain)},
+		{name: "stress", components: 6, values: averageGaussPoints(r.stress)},
+	}
+}
+
+// averageGaussPoints returns flattened average of tensors over Gauss points of each element
+func averageGaussPoints(tensors [][27]Tensor) []float64 {
+	values := make([]float64, 0, 6*len(tensors))
+	for _, points := range tensors {
+		var avg Tensor
+		for _, t := range points {
+			for k := range t {
+				avg[k] += t[k] / 27
+			}
+		}
+		values = append(values, avg[:]...)
+	}
+	return values
+}
+
+// SaveVTK writes result into the file, XML format is used for .vtu extension and legacy format otherwise
+func SaveVTK(path string, r *Result) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create VTK file: %w", err)
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".vtu") {
+		err = WriteVTU(file, r)
+	} else {
+		err = WriteVTK(file, r)
+	}
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("write VTK file: %w", err)
+	}
+	return file.Close()
+}
+
+// WriteVTK writes undeformed mesh with result fields in legacy ASCII VTK format
+func WriteVTK(w io.Writer, r *Result) error {
+	bw := bufio.NewWriter(w)
+	nodes, elements := r.mesh.akt, r.mesh.nt
+
+	_, _ = fmt.Fprintln(bw, "# vtk DataFile Version 3.0")
+	_, _ = fmt.Fprintln(bw, "Body deformation")
+	_, _ = fmt.Fprintln(bw, "ASCII")
+	_, _ = fmt.Fprintln(bw, "DATASET UNSTRUCTURED_GRID")
+
+	_, _ = fmt.Fprintf(bw, "POINTS %d double\n", len(nodes))
+	for _, p := range nodes {
+		_, _ = fmt.Fprintf(bw, "%s %s %s\n", vtkFloat(p[0]), vtkFloat(p[1]), vtkFloat(p[2]))
+	}
+
+	_, _ = fmt.Fprintf(bw, "CELLS %d %d\n", len(elements), len(elements)*(len(vtkNodeOrder)+1))
+	for _, element := range elements {
+		_, _ = fmt.Fprint(bw, len(vtkNodeOrder))
+		for _, i := range vtkNodeOrder {
+			_, _ = fmt.Fprintf(bw, " %d", element[i])
+		}
+		_, _ = fmt.Fprintln(bw)
+	}
+
+	_, _ = fmt.Fprintf(bw, "CELL_TYPES %d\n", len(elements))
+	for range elements {
+		_, _ = fmt.Fprintln(bw, vtkQuadraticHexahedron)
+	}
+
+	writeLegacyFields(bw, "POINT_DATA", len(nodes), vtkPointFields(r))
+	writeLegacyFields(bw, "CELL_DATA", len(elements), vtkCellFields(r))
+
+	return bw.Flush()
+}
+
+func writeLegacyFields(bw *bufio.Writer, section string, n int, fields []vtkField) {
+	if len(fields) == 0 {
+		return
+	}
+
+	_, _ = fmt.Fprintf(bw, "%s %d\n", section, n)
+	for _, field := range fields {
+		switch field.components {
+		case 1:
+			_, _ = fmt.Fprintf(bw, "SCALARS %s double 1\nLOOKUP_TABLE default\n", field.name)
+		case 3:
+			_, _ = fmt.Fprintf(bw, "VECTORS %s double\n", field.name)
+		default:
+			_, _ = fmt.Fprintf(bw, "FIELD FieldData 1\n%s %d %d double\n", field.name, field.components, n)
+		}
+		writeValues(bw, field.values, field.components)
+	}
+}
+
+// WriteVTU writes undeformed mesh with result fields in XML VTK unstructured grid format
+func WriteVTU(w io.Writer, r *Result) error {
+	bw := bufio.NewWriter(w)
+	nodes, elements := r.mesh.akt, r.mesh.nt
+
+	_, _ = fmt.Fprintln(bw, `<?xml version="1.0"?>`)
+	_, _ = fmt.Fprintln(bw, `<VTKFile type="UnstructuredGrid" version="0.1" byte_order="LittleEndian">`)
+	_, _ = fmt.Fprintln(bw, `  <UnstructuredGrid>`)
+	_, _ = fmt.Fprintf(bw, "    <Piece NumberOfPoints=\"%d\" NumberOfCells=\"%d\">\n", len(nodes), len(elements))
+
+	writeXMLFields(bw, "PointData", vtkPointFields(r))
+	writeXMLFields(bw, "CellData", vtkCellFields(r))
+
+	_, _ = fmt.Fprintln(bw, `      <Points>`)
+	_, _ = fmt.Fprintln(bw, `        <DataArray type="Float64" NumberOfComponents="3" format="ascii">`)
+	for _, p := range nodes {
+		_, _ = fmt.Fprintf(bw, "%s %s %s\n", vtkFloat(p[0]), vtkFloat(p[1]), vtkFloat(p[2]))
+	}
+	_, _ = fmt.Fprintln(bw, `        </DataArray>`)
+	_, _ = fmt.Fprintln(bw, `      </Points>`)
+
+	_, _ = fmt.Fprintln(bw, `      <Cells>`)
+	_, _ = fmt.Fprintln(bw, `        <DataArray type="Int64" Name="connectivity" format="ascii">`)
+	for _, element := range elements {
+		for j, i := range vtkNodeOrder {
+			if j > 0 {
+				_, _ = fmt.Fprint(bw, " ")
+			}
+			_, _ = fmt.Fprint(bw, element[i])
+		}
+		_, _ = fmt.Fprintln(bw)
+	}
+	_, _ = fmt.Fprintln(bw, `        </DataArray>`)
+	_, _ = fmt.Fprintln(bw, `        <DataArray type="Int64" Name="offsets" format="ascii">`)
+	for i := range elements {
+		_, _ = fmt.Fprintln(bw, (i+1)*len(vtkNodeOrder))
+	}
+	_, _ = fmt.Fprintln(bw, `        </DataArray>`)
+	_, _ = fmt.Fprintln(bw, `        <DataArray type="UInt8" Name="types" format="ascii">`)
+	for range elements {
+		_, _ = fmt.Fprintln(bw, vtkQuadraticHexahedron)
+	}
+	_, _ = fmt.Fprintln(bw, `        </DataArray>`)
+	_, _ = fmt.Fprintln(bw, `      </Cells>`)
+
+	_, _ = fmt.Fprintln(bw, `    </Piece>`)
+	_, _ = fmt.Fprintln(bw, `  </UnstructuredGrid>`)
+	_, _ = fmt.Fprintln(bw, `</VTKFile>`)
+
+	return bw.Flush()
+}
+
+func writeXMLFields(bw *bufio.Writer, section string, fields []vtkField) {
+	if len(fields) == 0 {
+		return
+	}
+
+	_, _ = fmt.Fprintf(bw, "      <%s>\n", section)
+	for _, field := range fields {
+		_, _ = fmt.Fprintf(bw,
+			"        <DataArray type=\"Float64\" Name=\"%s\" NumberOfComponents=\"%d\" format=\"ascii\">\n",
+			field.name, field.components)
+		writeValues(bw, field.values, field.components)
+		_, _ = fmt.Fprintln(bw, `        </DataArray>`)
+	}
+	_, _ = fmt.Fprintf(bw, "      </%s>\n", section)
+}
+
+// writeValues writes values with one point or cell per line
+func writeValues(bw *bufio.Writer, values []float64, components int) {
+	for i, v := range values {
+		_, _ = bw.WriteString(vtkFloat(v))
+		if (i+1)%components == 0 {
+			_ = bw.WriteByte('\n')
+		} else {
+			_ = bw.WriteByte(' ')
+		}
+	}
+}
+
+func vtkFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
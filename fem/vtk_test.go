@@ -0,0 +1,87 @@
+package fem
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// vtkEdges are corners joined by edges of quadratic hexahedron in order of its middle points in VTK: bottom face,
+// top face and then vertical edges
+var vtkEdges = [12][2]int{
+	{0, 1}, {1, 2}, {2, 3}, {3, 0},
+	{4, 5}, {5, 6}, {6, 7}, {7, 4},
+	{0, 4}, {1, 5}, {2, 6}, {3, 7},
+}
+
+// vtuConnectivity returns node indexes of cells from connectivity array of VTU file
+func vtuConnectivity(t *testing.T, vtu string) [][]int {
+	t.Helper()
+
+	_, after, ok := strings.Cut(vtu, `Name="connectivity" format="ascii">`)
+	if !ok {
+		t.Fatal("no connectivity")
+	}
+	data, _, _ := strings.Cut(after, "</DataArray>")
+
+	var cells [][]int
+	for _, line := range strings.Split(strings.TrimSpace(data), "\n") {
+		var cell []int
+		for _, field := range strings.Fields(line) {
+			node, err := strconv.Atoi(field)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cell = append(cell, node)
+		}
+		cells = append(cells, cell)
+	}
+	return cells
+}
+
+func TestVTKQuadraticHexahedronOrder(t *testing.T) {
+	mesh, bc := newTestProblem(t)
+	result, err := Solve(mesh, testMaterial, bc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var vtu bytes.Buffer
+	if err = WriteVTU(&vtu, result); err != nil {
+		t.Fatal(err)
+	}
+	cells := vtuConnectivity(t, vtu.String())
+	if len(cells) != len(mesh.elements) {
+		t.Fatalf("%d cells, expected %d", len(cells), len(mesh.elements))
+	}
+
+	nodes := mesh.Nodes()
+	sub := func(a, b [3]float64) [3]float64 { return [3]float64{a[0] - b[0], a[1] - b[1], a[2] - b[2]} }
+	for i, cell := range cells {
+		if len(cell) != 20 {
+			t.Fatalf("cell %d has %d nodes", i, len(cell))
+		}
+
+		// Corners 4 - 7 are above corners 0 - 3 and bottom face goes counterclockwise seen from the top, so the cell
+		// has positive volume
+		c := func(k int) [3]float64 { return nodes[cell[k]] }
+		for k := range 4 {
+			if d := sub(c(k+4), c(k)); d[0] != 0 || d[1] != 0 || d[2] <= 0 {
+				t.Fatalf("cell %d: corner %d isn't above corner %d", i, k+4, k)
+			}
+		}
+		normal := cross(sub(c(1), c(0)), sub(c(3), c(0)))
+		if height := sub(c(4), c(0)); normal[0]*height[0]+normal[1]*height[1]+normal[2]*height[2] <= 0 {
+			t.Fatalf("cell %d has negative volume", i)
+		}
+
+		for k, edge := range vtkEdges {
+			a, b := c(edge[0]), c(edge[1])
+			middle := [3]float64{(a[0] + b[0]) / 2, (a[1] + b[1]) / 2, (a[2] + b[2]) / 2}
+			if p := c(8 + k); p != middle {
+				t.Fatalf("cell %d: node %d at %v isn't middle of edge %v at %v", i, 8+k, p, edge, middle)
+			}
+		}
+	}
+}
@@ -49,3 +49,13 @@ func InputsToVec3[T InputTypes](in [3]*InputValue[T]) rl.Vector3 {
 func InputsToSlice3[T InputTypes](in [3]*InputValue[T]) [3]T {
 	return [3]T{in[0].Value, in[1].Value, in[2].Value}
 }
+
+// InputsEditing reports whether any of inputs is being edited
+func InputsEditing[T InputTypes](in ...*InputValue[T]) bool {
+	for _, i := range in {
+		if i.Edit {
+			return true
+		}
+	}
+	return false
+}
@@ -1,11 +1,20 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log/slog"
+	"math"
+	"slices"
 	"strconv"
+	"time"
 
 	gui "github.com/gen2brain/raylib-go/raygui"
 	rl "github.com/gen2brain/raylib-go/raylib"
+
+	"github.com/mymmrac/go-fem-body-deformation/fem"
 )
 
 var (
@@ -18,7 +27,17 @@ type BodyDrawOptions struct {
 	ShowVertexes bool
 }
 
+// solveOutcome is sent by background solve when it's finished
+type solveOutcome struct {
+	result *fem.Result
+	err    error
+}
+
 func main() {
+	scenarioFile := flag.String("scenario", "scenario.json", "Scenario file to save (Ctrl+S) and load (Ctrl+L), "+
+		"loaded on start if set")
+	flag.Parse()
+
 	rl.SetTraceLogLevel(rl.LogError)
 	rl.InitWindow(1280, 720, "Body Deformation")
 	defer rl.CloseWindow()
@@ -50,24 +69,141 @@ func main() {
 	yungaModule := NewInputValue(4.0)
 	poissonRatio := NewInputValue(0.3)
 	pressure := NewInputValue(2.0)
-
-	fem := &FEM{
-		zu: make(map[ElementSide]bool),
-		zp: make(map[ElementSide]bool),
+	var pressureField *fem.Expression // Pressure typed as expression of x, y and z instead of number
+	nodeForce := [3]*InputValue[float64]{
+		NewInputValue(0.0),
+		NewInputValue(0.0),
+		NewInputValue(0.0),
+	}
+	selectedNode := -1 // Node which force is edited, picked with Alt + right click
+	density := NewInputValue(0.0)
+	deformScale := NewInputValue(1.0)
+
+	mesh, err := fem.NewMesh(InputsToSlice3(bodySize), InputsToSlice3(bodySplit))
+	if err != nil {
+		slog.Error("Failed to build elements", "err", err)
+		return
 	}
-	body, bodyIndexes := fem.BuildElements(InputsToSlice3(bodySize), InputsToSlice3(bodySplit))
+	solver := fem.New(mesh)
+	bc := fem.NewBoundaryConditions()
+
+	// Young's modulus and Poisson's ratio inputs edit the current material of the table, other types of materials
+	// come only from scenario and aren't changed by them
+	materials := fem.UniformMaterials(fem.Material{
+		YoungsModulus: yungaModule.Value,
+		PoissonRatio:  poissonRatio.Value,
+	}, len(mesh.Elements()))
+	currentMaterial := int32(0)
+	storeMaterial := func() {
+		if materials.Table[currentMaterial].Type == fem.MaterialIsotropic {
+			materials.Table[currentMaterial] = fem.Material{
+				YoungsModulus: yungaModule.Value,
+				PoissonRatio:  poissonRatio.Value,
+			}
+		}
+	}
+	showMaterial := func() {
+		yungaModule.Value = materials.Table[currentMaterial].YoungsModulus
+		yungaModule.UpdateText()
+		poissonRatio.Value = materials.Table[currentMaterial].PoissonRatio
+		poissonRatio.UpdateText()
+	}
+	var result *fem.Result
 	var deformedBody [][3]float64
 
+	contourOptions := "No contour"
+	for _, field := range fem.Fields {
+		contourOptions += ";" + field.String()
+	}
+	contourField := int32(0) // 0 - no contour, otherwise index of the field + 1
+	var contourValues []float64
+	var contourMin, contourMax float64
+	updateContour := func() {
+		if result == nil || contourField == 0 {
+			contourValues = nil
+			return
+		}
+
+		contourValues = result.NodalField(fem.Fields[contourField-1])
+		contourMin, contourMax = slices.Min(contourValues), slices.Max(contourValues)
+	}
+
 	{ // Fix bottom and push on top
 		a, b, c := bodySplit[0].Value, bodySplit[1].Value, bodySplit[2].Value
 		for i := range a * b {
-			fem.zu[ElementSide{i, 4}] = true
-			fem.zp[ElementSide{i + a*b*(c-1), 5}] = true
+			bc.Fixed[fem.ElementSide{Element: i, Side: 4}] = fem.AxesAll
+			bc.Pushed[fem.ElementSide{Element: i + a*b*(c-1), Side: 5}] = true
+		}
+	}
+
+	// Density gives self-weight, body is accelerated by gravity along -z unless scenario has other acceleration
+	applyBodyForce := func() {
+		bc.BodyForce.Density = density.Value
+		if bc.BodyForce.Acceleration == [3]float64{} {
+			bc.BodyForce.Acceleration = [3]float64{0, 0, -9.81}
+		}
+	}
+
+	saveScenario := func() error {
+		bc.Pressure = pressure.Value
+		bc.PressureField = pressureField
+		applyBodyForce()
+		storeMaterial()
+		return fem.NewMaterialsScenario(mesh, materials, bc).Save(*scenarioFile)
+	}
+
+	loadScenario := func() error {
+		scenario, err := fem.LoadScenario(*scenarioFile)
+		if err != nil {
+			return err
+		}
+		newMesh, err := scenario.Mesh()
+		if err != nil {
+			return err
+		}
+
+		for i := range 3 {
+			bodySize[i].Value = scenario.Size[i]
+			bodySize[i].UpdateText()
+			bodySplit[i].Value = scenario.Split[i]
+			bodySplit[i].UpdateText()
+		}
+		materials = scenario.MaterialsTable()
+		currentMaterial = 0
+		showMaterial()
+		pressure.Value = scenario.Pressure
+		pressure.UpdateText()
+		pressureField = scenario.PressureField
+		if pressureField != nil {
+			pressure.Text = pressureField.String()
+		}
+		density.Value = 0
+		if scenario.BodyForce != nil {
+			density.Value = scenario.BodyForce.Density
+		}
+		density.UpdateText()
+
+		mesh = newMesh
+		solver = fem.New(mesh)
+		bc = scenario.BoundaryConditions()
+		selectedNode = -1
+		result = nil
+		deformedBody = nil
+		updateContour()
+		return nil
+	}
+
+	scenarioSet := false
+	flag.Visit(func(f *flag.Flag) { scenarioSet = scenarioSet || f.Name == "scenario" })
+	if scenarioSet {
+		if err = loadScenario(); err != nil {
+			slog.Error("Failed to load scenario", "err", err)
+			return
 		}
 	}
 
 	// TODO: Remove this
-	// fem.zp[ElementSide{92, 0}] = true
+	// bc.Pushed[fem.ElementSide{Element: 92, Side: 0}] = true
 	// var rotation = rl.MatrixRotate(rl.GetCameraUp(&camera), 4.5)
 	// var view = rl.Vector3Subtract(camera.Position, camera.Target)
 	// view = rl.Vector3Transform(view, rotation)
@@ -96,12 +232,26 @@ func main() {
 	showOriginal := true
 	showForces := true
 	showGrid := true
+	animateDeformation := false
 	opt := BodyDrawOptions{
 		ShowEdges:    true,
 		ShowVertexes: false,
 	}
 
-	running := 0
+	var (
+		solving          bool                 // Background solve is running, until its outcome is received
+		cancelSolve      context.CancelFunc   // Cancels running solve
+		progressCh       chan fem.Progress    // Progress of running solve
+		solveDone        chan solveOutcome    // Outcome of running solve
+		progress         fem.Progress         // Last received progress
+		solverMethod     fem.SolverMethod     // Method selected for all solvers
+		constraintMethod fem.ConstraintMethod // Constraint method selected for all solvers
+	)
+	// Preconditioner and tolerance of conjugate gradient selected for all solvers, tolerance is an index in tolerances
+	preconditioner := fem.PreconditionerIncompleteCholesky
+	tolerances := [...]float64{1e-6, 1e-8, 1e-10, 1e-12}
+	tolerance := 1
+	var lastErr error // Error of the last body update or run, shown until next successful one
 
 	quad := [6]int{1, 3, 2, 1, 0, 3}
 
@@ -112,17 +262,28 @@ func main() {
 			padding+inputHeight*2+padding+padding,
 		)
 		bottomLeftUiRect := rl.NewRectangle(
-			0, float32(rl.GetScreenHeight())-(padding+inputHeight*3+padding*2+padding),
+			0, float32(rl.GetScreenHeight())-(padding+inputHeight*6+padding*5+padding),
 			padding+inputWidth*2+padding+padding,
-			padding+inputHeight*3+padding*2+padding,
+			padding+inputHeight*6+padding*5+padding,
 		)
-
-		if rl.IsKeyPressed(rl.KeySpace) {
-			cameraOrbiting = !cameraOrbiting
+		topRightUiRect := rl.NewRectangle(
+			float32(rl.GetScreenWidth())-(padding+inputWidth*2+padding), 0,
+			padding+inputWidth*2+padding,
+			padding+inputHeight+padding,
+		)
+		nodeUiRect := rl.NewRectangle(
+			0, bottomLeftUiRect.Y-(padding+inputHeight*2+padding+padding),
+			padding+(inputWidth+padding)*3,
+			padding+inputHeight*2+padding+padding,
+		)
+		if selectedNode < 0 {
+			nodeUiRect = rl.Rectangle{}
 		}
 
 		if rl.IsMouseButtonDown(rl.MouseButtonLeft) && (!rl.CheckCollisionPointRec(rl.GetMousePosition(), topLeftUiRect) &&
-			!rl.CheckCollisionPointRec(rl.GetMousePosition(), bottomLeftUiRect)) {
+			!rl.CheckCollisionPointRec(rl.GetMousePosition(), bottomLeftUiRect) &&
+			!rl.CheckCollisionPointRec(rl.GetMousePosition(), topRightUiRect) &&
+			!rl.CheckCollisionPointRec(rl.GetMousePosition(), nodeUiRect)) {
 			md := rl.GetMouseDelta()
 			rl.CameraYaw(&camera, -md.X*0.003, 1)
 			rl.CameraPitch(&camera, -md.Y*0.003, 1, 1, 0)
@@ -134,84 +295,124 @@ func main() {
 		}
 
 		rl.CameraMoveToTarget(&camera, -rl.GetMouseWheelMove())
-		if rl.IsKeyPressed(rl.KeyKpSubtract) {
-			rl.CameraMoveToTarget(&camera, 2.0)
-		}
-		if rl.IsKeyPressed(rl.KeyKpAdd) {
-			rl.CameraMoveToTarget(&camera, -2.0)
-		}
 
-		const scaleFactor = 0.95
-		if rl.IsKeyPressed(rl.KeyMinus) {
-			inputWidth *= scaleFactor
-			inputHeight *= scaleFactor
-			padding *= scaleFactor
-		}
-		if rl.IsKeyPressed(rl.KeyEqual) {
-			inputWidth /= scaleFactor
-			inputHeight /= scaleFactor
-			padding /= scaleFactor
-		}
+		// Shortcuts are letters and signs which are typed into inputs too
+		editing := InputsEditing(bodySize[:]...) || InputsEditing(bodySplit[:]...) || InputsEditing(nodeForce[:]...) ||
+			InputsEditing(yungaModule, poissonRatio, pressure, density, deformScale)
+		if !editing {
+			if rl.IsKeyPressed(rl.KeySpace) {
+				cameraOrbiting = !cameraOrbiting
+			}
 
-		if rl.IsKeyPressed(rl.KeyO) {
-			showOriginal = !showOriginal
-		}
-		if showOriginal && rl.IsKeyPressed(rl.KeyN) {
-			showNumbers = !showNumbers
-		}
-		if showOriginal && rl.IsKeyPressed(rl.KeyF) {
-			showForces = !showForces
-		}
-		if rl.IsKeyPressed(rl.KeyG) {
-			showGrid = !showGrid
-		}
-		if rl.IsKeyPressed(rl.KeyE) {
-			opt.ShowEdges = !opt.ShowEdges
-		}
-		if rl.IsKeyPressed(rl.KeyV) {
-			opt.ShowVertexes = !opt.ShowVertexes
-		}
+			if rl.IsKeyPressed(rl.KeyKpSubtract) {
+				rl.CameraMoveToTarget(&camera, 2.0)
+			}
+			if rl.IsKeyPressed(rl.KeyKpAdd) {
+				rl.CameraMoveToTarget(&camera, -2.0)
+			}
 
-		if showOriginal && showForces {
-			if rl.IsKeyPressed(rl.KeyC) {
-				if rl.IsKeyDown(rl.KeyLeftShift) {
-					clear(fem.zu)
+			const scaleFactor = 0.95
+			if rl.IsKeyPressed(rl.KeyMinus) {
+				inputWidth *= scaleFactor
+				inputHeight *= scaleFactor
+				padding *= scaleFactor
+			}
+			if rl.IsKeyPressed(rl.KeyEqual) {
+				inputWidth /= scaleFactor
+				inputHeight /= scaleFactor
+				padding /= scaleFactor
+			}
+
+			if rl.IsKeyDown(rl.KeyLeftControl) && rl.IsKeyPressed(rl.KeyS) {
+				if err = saveScenario(); err != nil {
+					slog.Error("Failed to save scenario", "err", err)
+					lastErr = err
+				} else {
+					slog.Info("Scenario saved", "file", *scenarioFile)
+				}
+			}
+			if rl.IsKeyDown(rl.KeyLeftControl) && rl.IsKeyPressed(rl.KeyL) {
+				if err = loadScenario(); err != nil {
+					slog.Error("Failed to load scenario", "err", err)
+					lastErr = err
 				} else {
-					clear(fem.zp)
+					slog.Info("Scenario loaded", "file", *scenarioFile)
+					lastErr = nil
 				}
 			}
 
-			if rl.IsKeyPressed(rl.KeyT) {
-				a, b, c := bodySplit[0].Value, bodySplit[1].Value, bodySplit[2].Value
-				fixOrPush := rl.IsKeyDown(rl.KeyLeftShift)
-				for i := range a * b {
-					es := ElementSide{i + a*b*(c-1), 5}
-					if fixOrPush {
-						fem.zu[es] = true
-						fem.zp[es] = false
-					} else {
-						fem.zu[es] = false
-						fem.zp[es] = true
-					}
+			if rl.IsKeyPressed(rl.KeyO) {
+				showOriginal = !showOriginal
+			}
+			if showOriginal && rl.IsKeyPressed(rl.KeyN) {
+				showNumbers = !showNumbers
+			}
+			if showOriginal && rl.IsKeyPressed(rl.KeyF) {
+				showForces = !showForces
+			}
+			if rl.IsKeyPressed(rl.KeyG) {
+				showGrid = !showGrid
+			}
+			if rl.IsKeyPressed(rl.KeyE) {
+				opt.ShowEdges = !opt.ShowEdges
+			}
+			if rl.IsKeyPressed(rl.KeyV) {
+				opt.ShowVertexes = !opt.ShowVertexes
+			}
+			if rl.IsKeyPressed(rl.KeyA) {
+				animateDeformation = !animateDeformation
+				if !animateDeformation && result != nil {
+					deformedBody = result.ScaledNodes(deformScale.Value)
 				}
 			}
 
-			if rl.IsKeyPressed(rl.KeyB) {
-				a, b, _ := bodySplit[0].Value, bodySplit[1].Value, bodySplit[2].Value
-				fixOrPush := rl.IsKeyDown(rl.KeyLeftShift)
-				for i := range a * b {
-					es := ElementSide{i, 4}
-					if fixOrPush {
-						fem.zu[es] = true
-						fem.zp[es] = false
+			if showOriginal && showForces {
+				if rl.IsKeyPressed(rl.KeyC) {
+					if rl.IsKeyDown(rl.KeyLeftShift) {
+						clear(bc.Fixed)
 					} else {
-						fem.zu[es] = false
-						fem.zp[es] = true
+						clear(bc.Pushed)
+					}
+				}
+
+				if rl.IsKeyPressed(rl.KeyT) {
+					a, b, c := bodySplit[0].Value, bodySplit[1].Value, bodySplit[2].Value
+					fixOrPush := rl.IsKeyDown(rl.KeyLeftShift)
+					for i := range a * b {
+						es := fem.ElementSide{Element: i + a*b*(c-1), Side: 5}
+						if fixOrPush {
+							bc.Fixed[es] = fem.AxesAll
+							bc.Pushed[es] = false
+						} else {
+							bc.Fixed[es] = 0
+							bc.Pushed[es] = true
+						}
+					}
+				}
+
+				if rl.IsKeyPressed(rl.KeyB) {
+					a, b, _ := bodySplit[0].Value, bodySplit[1].Value, bodySplit[2].Value
+					fixOrPush := rl.IsKeyDown(rl.KeyLeftShift)
+					for i := range a * b {
+						es := fem.ElementSide{Element: i, Side: 4}
+						if fixOrPush {
+							bc.Fixed[es] = fem.AxesAll
+							bc.Pushed[es] = false
+						} else {
+							bc.Fixed[es] = 0
+							bc.Pushed[es] = true
+						}
 					}
 				}
 			}
 		}
 
+		if animateDeformation && result != nil {
+			const animationSpeed = 2.0
+			t := (1 - math.Cos(rl.GetTime()*animationSpeed)) / 2
+			deformedBody = result.ScaledNodes(deformScale.Value * t)
+		}
+
 		ray := rl.GetScreenToWorldRay(rl.GetMousePosition(), camera)
 		rl.BeginDrawing()
 		{
@@ -228,13 +429,20 @@ func main() {
 				origin.Y = 0
 
 				if showOriginal {
-					drawBody(body, bodyIndexes, origin, rl.Gray, rl.Blue, showNumbers, opt)
+					// Edges of body made of few materials are tinted by material
+					if opt.ShowEdges && slices.ContainsFunc(materials.Elements, func(m int) bool { return m != 0 }) {
+						drawMaterialEdges(mesh, materials, origin)
+						drawBody(mesh.Nodes(), mesh.SurfaceIndexes(), origin, rl.Gray, rl.Blue, showNumbers,
+							BodyDrawOptions{ShowVertexes: opt.ShowVertexes})
+					} else {
+						drawBody(mesh.Nodes(), mesh.SurfaceIndexes(), origin, rl.Gray, rl.Blue, showNumbers, opt)
+					}
 
 					if showForces {
 						a, b, c := bodySplit[0].Value, bodySplit[1].Value, bodySplit[2].Value
 
 						collisions := make(map[int]map[int]rl.RayCollision)
-						for i, cube := range fem.elements {
+						for i := range mesh.Elements() {
 							y := i / (b * a)
 							z := (i % (b * a)) / a
 							x := i % a
@@ -267,7 +475,7 @@ func main() {
 							}
 
 							for _, n := range sides {
-								side := fem.choseCubeSide(cube, n)
+								side := mesh.Side(fem.ElementSide{Element: i, Side: n})
 								collision := rl.GetRayCollisionQuad(ray,
 									transformPoint(side[0], origin), transformPoint(side[1], origin),
 									transformPoint(side[2], origin), transformPoint(side[3], origin),
@@ -297,7 +505,7 @@ func main() {
 							}
 						}
 
-						for i, cube := range fem.elements {
+						for i := range mesh.Elements() {
 							y := i / (b * a)
 							z := (i % (b * a)) / a
 							x := i % a
@@ -330,39 +538,84 @@ func main() {
 							}
 
 							for n := range 6 {
-								var chosen int // 0 - nothing, 1 - fix, 2 - push
-								es := ElementSide{i, n}
-								if fem.zu[es] {
+								var chosen int // 0 - nothing, 1 - fix, 2 - push, 3 - displace, 4 - traction
+								es := fem.ElementSide{Element: i, Side: n}
+								_, hasTraction := bc.Tractions[es]
+								if bc.Fixed[es] != 0 {
 									chosen = 1
-								} else if fem.zp[es] {
+								} else if bc.Pushed[es] {
 									chosen = 2
+								} else if bc.Displaced[es].Axes != 0 {
+									chosen = 3
+								} else if hasTraction {
+									chosen = 4
 								}
 
 								if (closestCollisionI == i && closestCollisionN == n) || chosen != 0 {
-									if (closestCollisionI == i && closestCollisionN == n) && rl.IsMouseButtonPressed(rl.MouseButtonRight) {
-										if rl.IsKeyDown(rl.KeyLeftShift) {
-											fem.zu[es] = !(chosen != 0)
-											fem.zp[es] = false
+									if (closestCollisionI == i && closestCollisionN == n) && rl.IsMouseButtonPressed(rl.MouseButtonRight) &&
+										!rl.IsKeyDown(rl.KeyLeftAlt) {
+										if rl.IsKeyDown(rl.KeyM) {
+											// Assign current material to the element, or to its layer along z with shift
+											elements := []int{i}
+											if rl.IsKeyDown(rl.KeyLeftShift) {
+												elements = mesh.LayerElements(2, mesh.ElementPosition(i)[2])
+											}
+											for _, element := range elements {
+												materials.Elements[element] = int(currentMaterial)
+											}
+										} else if rl.IsKeyDown(rl.KeyLeftControl) {
+											// Cycle fixed axes of the side, or of the whole face with shift
+											axes := nextFixedAxes(bc.Fixed[es], n)
+											sides := []fem.ElementSide{es}
+											if rl.IsKeyDown(rl.KeyLeftShift) {
+												sides = mesh.FaceSides(n)
+											}
+											for _, s := range sides {
+												bc.Fixed[s] = axes
+												bc.Pushed[s] = false
+											}
+										} else if rl.IsKeyDown(rl.KeyLeftShift) {
+											delete(bc.Displaced, es)
+											delete(bc.Tractions, es)
+											if chosen != 0 {
+												bc.Fixed[es] = 0
+											} else {
+												bc.Fixed[es] = fem.AxesAll
+											}
+											bc.Pushed[es] = false
 										} else {
-											fem.zu[es] = false
-											fem.zp[es] = !(chosen != 0)
+											delete(bc.Displaced, es)
+											delete(bc.Tractions, es)
+											bc.Fixed[es] = 0
+											bc.Pushed[es] = !(chosen != 0)
 										}
 									}
 
-									side := fem.choseCubeSide(cube, n)
+									side := mesh.Side(fem.ElementSide{Element: i, Side: n})
 
 									clr := rl.ColorAlpha(rl.LightGray, 0.7)
 
 									if chosen == 1 {
-										clr = rl.ColorAlpha(rl.Blue, 0.4)
+										fixedClr := fixedAxesColor(bc.Fixed[es], n)
+										clr = rl.ColorAlpha(fixedClr, 0.4)
 										if collisions[i] != nil && collisions[i][n].Hit {
-											clr = rl.ColorAlpha(rl.Blue, 0.7)
+											clr = rl.ColorAlpha(fixedClr, 0.7)
 										}
 									} else if chosen == 2 {
 										clr = rl.ColorAlpha(rl.Orange, 0.4)
 										if collisions[i] != nil && collisions[i][n].Hit {
 											clr = rl.ColorAlpha(rl.Orange, 0.7)
 										}
+									} else if chosen == 3 {
+										clr = rl.ColorAlpha(rl.Magenta, 0.4)
+										if collisions[i] != nil && collisions[i][n].Hit {
+											clr = rl.ColorAlpha(rl.Magenta, 0.7)
+										}
+									} else if chosen == 4 {
+										clr = rl.ColorAlpha(rl.Lime, 0.4)
+										if collisions[i] != nil && collisions[i][n].Hit {
+											clr = rl.ColorAlpha(rl.Lime, 0.7)
+										}
 									}
 
 									// rl.DrawBillboard(camera, numbers[0], rl.Vector3Add(transformPoint(side[0], origin), rl.Vector3{Y: 0.2}), 0.2, rl.Black)
@@ -375,10 +628,45 @@ func main() {
 								}
 							}
 						}
+
+						// Nodes are picked among surface ones by the closest sphere hit by the mouse ray
+						const nodeRadius = 0.08
+						if rl.IsKeyDown(rl.KeyLeftAlt) {
+							hoveredNode := -1
+							var hoveredCollision rl.RayCollision
+							for _, idx := range mesh.SurfaceIndexes() {
+								collision := rl.GetRayCollisionSphere(ray, transformPoint(mesh.Nodes()[idx], origin), nodeRadius)
+								if collision.Hit && (hoveredNode == -1 || collision.Distance < hoveredCollision.Distance) {
+									hoveredNode, hoveredCollision = idx, collision
+								}
+							}
+							if hoveredNode >= 0 {
+								rl.DrawSphere(transformPoint(mesh.Nodes()[hoveredNode], origin), nodeRadius, rl.ColorAlpha(rl.Gold, 0.7))
+							}
+							if rl.IsMouseButtonPressed(rl.MouseButtonRight) {
+								selectedNode = hoveredNode
+								if selectedNode >= 0 {
+									force := bc.NodalLoads[selectedNode]
+									for i := range 3 {
+										nodeForce[i].Value = force[i]
+										nodeForce[i].UpdateText()
+									}
+								}
+							}
+						}
+						for node := range bc.NodalLoads {
+							rl.DrawSphere(transformPoint(mesh.Nodes()[node], origin), nodeRadius, rl.Maroon)
+						}
+						if selectedNode >= 0 {
+							rl.DrawSphereWires(transformPoint(mesh.Nodes()[selectedNode], origin), nodeRadius*1.5, 6, 6, rl.Gold)
+						}
 					}
 				}
+				if deformedBody != nil && contourValues != nil {
+					drawContour(mesh, deformedBody, contourValues, contourMin, contourMax, origin)
+				}
 				if deformedBody != nil {
-					drawBody(deformedBody, bodyIndexes, origin, rl.Red, rl.Green, false, opt)
+					drawBody(deformedBody, mesh.SurfaceIndexes(), origin, rl.Red, rl.Green, false, opt)
 				}
 
 				const thickness = 0.02
@@ -398,9 +686,65 @@ func main() {
 			rl.DrawRectangleRec(topLeftUiRect, rl.RayWhite)
 			rl.DrawRectangleLinesEx(topLeftUiRect, 1, rl.Gray)
 
+			if result != nil && !solving {
+				drawReactions(result, int32(padding), int32(topLeftUiRect.Height+padding))
+			}
+
 			rl.DrawRectangleRec(bottomLeftUiRect, rl.RayWhite)
 			rl.DrawRectangleLinesEx(bottomLeftUiRect, 1, rl.Gray)
 
+			rl.DrawRectangleRec(topRightUiRect, rl.RayWhite)
+			rl.DrawRectangleLinesEx(topRightUiRect, 1, rl.Gray)
+
+			// Force of the picked node, zero force removes the load
+			if selectedNode >= 0 {
+				rl.DrawRectangleRec(nodeUiRect, rl.RayWhite)
+				rl.DrawRectangleLinesEx(nodeUiRect, 1, rl.Gray)
+
+				gui.Label(
+					rl.NewRectangle(nodeUiRect.X+padding, nodeUiRect.Y+padding, inputWidth*3, inputHeight),
+					fmt.Sprintf("Force of node %d (x, y, z)", selectedNode),
+				)
+				for i := range 3 {
+					if gui.TextBox(
+						rl.NewRectangle(nodeUiRect.X+padding+(inputWidth+padding)*float32(i), nodeUiRect.Y+padding+inputHeight+padding, inputWidth, inputHeight),
+						&nodeForce[i].Text, inputTextSize, nodeForce[i].Edit,
+					) {
+						nodeForce[i].ToggleEdit()
+						v, err := strconv.ParseFloat(nodeForce[i].Text, 64)
+						if err != nil {
+							slog.Error("Invalid node force value", "err", err)
+						} else {
+							nodeForce[i].Value = max(min(v, 100000.0), -100000.0)
+						}
+						nodeForce[i].UpdateText()
+
+						force := InputsToSlice3(nodeForce)
+						if force == [3]float64{} {
+							delete(bc.NodalLoads, selectedNode)
+						} else {
+							bc.NodalLoads[selectedNode] = force
+						}
+					}
+				}
+			}
+
+			// Contour
+			if newContourField := gui.ComboBox(
+				rl.NewRectangle(topRightUiRect.X+padding, padding, inputWidth*2, inputHeight),
+				contourOptions, contourField,
+			); newContourField != contourField {
+				contourField = newContourField
+				updateContour()
+			}
+			if contourValues != nil {
+				const legendWidth, legendHeight = 24, 240
+				drawLegend(rl.NewRectangle(
+					float32(rl.GetScreenWidth())-padding-legendWidth, topRightUiRect.Height+padding+32,
+					legendWidth, legendHeight,
+				), fem.Fields[contourField-1], contourMin, contourMax)
+			}
+
 			bodyUpdated := false
 
 			// Sizes
@@ -482,45 +826,323 @@ func main() {
 				&pressure.Text, inputTextSize, pressure.Edit,
 			) {
 				pressure.ToggleEdit()
-				v, err := strconv.ParseFloat(pressure.Text, 64)
-				if err != nil {
+				// Text that isn't a number is parsed as pressure field like 2 + 0.5*z
+				if v, err := strconv.ParseFloat(pressure.Text, 64); err == nil {
+					pressure.Value = max(min(v, 10000.0), 0.01)
+					pressureField = nil
+					pressure.UpdateText()
+				} else if field, err := fem.ParseExpression(pressure.Text); err == nil {
+					pressureField = field
+				} else {
 					slog.Error("Invalid pressure value", "err", err)
+					pressure.UpdateText()
+					if pressureField != nil {
+						pressure.Text = pressureField.String()
+					}
+				}
+			}
+
+			// Deformation scale
+			gui.Label(rl.NewRectangle(bottomLeftUiRect.X+padding, bottomLeftUiRect.Y+padding+(padding+inputHeight)*3, inputWidth, inputHeight), "Deform scale")
+			if gui.TextBox(
+				rl.NewRectangle(bottomLeftUiRect.X+padding+inputWidth+padding, bottomLeftUiRect.Y+padding+(padding+inputHeight)*3, inputWidth, inputHeight),
+				&deformScale.Text, inputTextSize, deformScale.Edit,
+			) {
+				deformScale.ToggleEdit()
+				v, err := strconv.ParseFloat(deformScale.Text, 64)
+				if err != nil {
+					slog.Error("Invalid deformation scale value", "err", err)
 				} else {
-					pressure.Value = max(min(v, 10000.0), 0.01)
+					deformScale.Value = max(min(v, 1000000.0), 0.0)
+					if result != nil {
+						deformedBody = result.ScaledNodes(deformScale.Value)
+					}
 				}
-				pressure.UpdateText()
+				deformScale.UpdateText()
+			}
+
+			// Density
+			gui.Label(rl.NewRectangle(bottomLeftUiRect.X+padding, bottomLeftUiRect.Y+padding+(padding+inputHeight)*4, inputWidth, inputHeight), "Density")
+			if gui.TextBox(
+				rl.NewRectangle(bottomLeftUiRect.X+padding+inputWidth+padding, bottomLeftUiRect.Y+padding+(padding+inputHeight)*4, inputWidth, inputHeight),
+				&density.Text, inputTextSize, density.Edit,
+			) {
+				density.ToggleEdit()
+				v, err := strconv.ParseFloat(density.Text, 64)
+				if err != nil {
+					slog.Error("Invalid density value", "err", err)
+				} else {
+					density.Value = max(min(v, 100000.0), 0.0)
+				}
+				density.UpdateText()
+			}
+
+			// Material edited by Young's modulus and Poisson's ratio inputs, new material starts as a copy of the current one
+			materialLabel := "Material"
+			if materialType := materials.Table[currentMaterial].Type; materialType != fem.MaterialIsotropic {
+				materialLabel = materialType.String()
+			}
+			gui.Label(rl.NewRectangle(bottomLeftUiRect.X+padding, bottomLeftUiRect.Y+padding+(padding+inputHeight)*5, inputWidth, inputHeight), materialLabel)
+			newMaterial := currentMaterial
+			gui.Spinner(
+				rl.NewRectangle(bottomLeftUiRect.X+padding+inputWidth+padding, bottomLeftUiRect.Y+padding+(padding+inputHeight)*5, inputWidth, inputHeight),
+				"", &newMaterial, 0, max(len(materialColors), len(materials.Table))-1, false,
+			)
+			if newMaterial != currentMaterial {
+				storeMaterial()
+				for int(newMaterial) >= len(materials.Table) {
+					materials.Table = append(materials.Table, materials.Table[currentMaterial])
+				}
+				currentMaterial = newMaterial
+				showMaterial()
 			}
 
 			if bodyUpdated {
-				body, bodyIndexes = fem.BuildElements(InputsToSlice3(bodySize), InputsToSlice3(bodySplit))
+				newMesh, err := fem.NewMesh(InputsToSlice3(bodySize), InputsToSlice3(bodySplit))
+				if err != nil {
+					slog.Error("Failed to build elements", "err", err)
+					lastErr = err
+				} else {
+					lastErr = nil
+					mesh = newMesh
+					solver = fem.New(mesh)
+					bc.Clear()
+					selectedNode = -1
+					materials.Elements = make([]int, len(mesh.Elements()))
+				}
+				if solving {
+					cancelSolve()
+				}
+				result = nil
 				deformedBody = nil
+				updateContour()
 			}
 
 			// Run
-			runBtnText := "Run"
-			if running > 0 {
-				runBtnText = "Running..."
-				running++
-			}
-			if gui.Button(
-				rl.NewRectangle(float32(rl.GetScreenWidth())-padding-inputWidth, float32(rl.GetScreenHeight())-padding-inputHeight, inputWidth, inputHeight),
-				runBtnText,
-			) || rl.IsKeyPressed(rl.KeyEnter) {
-				running = 1
-			} else if running > 10 {
+			if solving {
+				for received := true; received; {
+					select {
+					case progress = <-progressCh:
+					default:
+						received = false
+					}
+				}
+
+				select {
+				case outcome := <-solveDone:
+					solving = false
+					cancelSolve()
+
+					switch {
+					case errors.Is(outcome.err, context.Canceled):
+						slog.Info("Solve canceled")
+					case outcome.err != nil:
+						slog.Error("Failed to solve", "err", outcome.err)
+						lastErr = outcome.err
+						result = nil
+						deformedBody = nil
+						updateContour()
+					case outcome.result.Mesh() != mesh:
+						slog.Info("Solve result dropped, body was changed")
+					default:
+						lastErr = nil
+						result = outcome.result
+						deformedBody = result.ScaledNodes(deformScale.Value)
+						updateContour()
+					}
+				default:
+				}
+			}
+
+			runBounds := rl.NewRectangle(
+				float32(rl.GetScreenWidth())-padding-inputWidth, float32(rl.GetScreenHeight())-padding-inputHeight,
+				inputWidth, inputHeight,
+			)
+			// Solver settings can't be changed while solve is running, solver is used by it
+			if !solving {
+				solver.Method = fem.SolverMethod(gui.ComboBox(
+					rl.NewRectangle(runBounds.X, runBounds.Y-padding-inputHeight, inputWidth, inputHeight),
+					"CG;Cholesky", int32(solverMethod),
+				))
+				solverMethod = solver.Method
+
+				solver.Constraints = fem.ConstraintMethod(gui.ComboBox(
+					rl.NewRectangle(runBounds.X, runBounds.Y-(padding+inputHeight)*2, inputWidth, inputHeight),
+					"Penalty;Elimination", int32(constraintMethod),
+				))
+				constraintMethod = solver.Constraints
+
+				solver.Preconditioner = fem.Preconditioner(gui.ComboBox(
+					rl.NewRectangle(runBounds.X, runBounds.Y-(padding+inputHeight)*3, inputWidth, inputHeight),
+					"No precon;Jacobi;Block Jacobi;IC(0)", int32(preconditioner),
+				))
+				preconditioner = solver.Preconditioner
+
+				tolerance = int(gui.ComboBox(
+					rl.NewRectangle(runBounds.X, runBounds.Y-(padding+inputHeight)*4, inputWidth, inputHeight),
+					"Tol 1e-6;Tol 1e-8;Tol 1e-10;Tol 1e-12", int32(tolerance),
+				))
+				solver.Tolerance = tolerances[tolerance]
+			}
+
+			if solving {
+				if gui.Button(runBounds, "Cancel") {
+					cancelSolve()
+				}
+
+				progressText := progress.Stage.String()
+				if progress.Stage == fem.StageSolve {
+					progressText = fmt.Sprintf("%s %d, residual %.1e", progressText, progress.Done, progress.Residual)
+				}
+				gui.ProgressBar(
+					rl.NewRectangle(runBounds.X-padding-inputWidth*3, runBounds.Y, inputWidth*3, inputHeight),
+					progressText+" ", "", float32(progress.Fraction()), 0, 1,
+				)
+			} else if gui.Button(runBounds, "Run") || rl.IsKeyPressed(rl.KeyEnter) {
 				slog.Info("Running...",
 					"bodySize", InputsToVec3(bodySize),
 					"bodySplits", InputsToVec3(bodySplit),
-					"yungaModule", yungaModule, "poissonRatio", poissonRatio, "pressure", pressure,
+					"yungaModule", yungaModule, "poissonRatio", poissonRatio, "pressure", pressure, "density", density,
+				)
+				bc.Pressure = pressure.Value
+				bc.PressureField = pressureField
+				applyBodyForce()
+				storeMaterial()
+
+				var ctx context.Context
+				ctx, cancelSolve = context.WithCancel(context.Background())
+				progressCh = make(chan fem.Progress, 1)
+				solveDone = make(chan solveOutcome, 1)
+				progress = fem.Progress{}
+				solving = true
+
+				// Solver reads boundary conditions while running, so it gets a copy that isn't changed by UI
+				solver.Progress = progressCh
+				go func(solver *fem.FEM, materials *fem.Materials, bc *fem.BoundaryConditions, done chan<- solveOutcome) {
+					newResult, err := solver.SolveMaterials(ctx, materials, bc)
+					done <- solveOutcome{result: newResult, err: err}
+				}(solver, materials.Clone(), bc.Clone(), solveDone)
+			}
+
+			if !solving && (lastErr != nil || result != nil) {
+				const statusTextSize = 20
+				var statusText string
+				statusColor := rl.DarkGray
+				if lastErr != nil {
+					statusText = "Error: " + lastErr.Error()
+					statusColor = rl.Red
+				} else {
+					stats := result.Stats()
+					switch stats.Method {
+					case fem.SolverCholesky:
+						statusText = fmt.Sprintf("Cholesky: %d nonzeros, residual %.1e, %s",
+							stats.FactorNonzeros, stats.Residual, stats.Duration.Round(time.Millisecond))
+						if !stats.Factorized {
+							statusText += " (reused)"
+						}
+					default:
+						statusText = fmt.Sprintf("CG (%s): %d iterations, residual %.1e, %s",
+							stats.Preconditioner, stats.Iterations, stats.Residual, stats.Duration.Round(time.Millisecond))
+					}
+					if len(bc.Displaced) > 0 || len(bc.DisplacedNodes) > 0 {
+						r := result.DisplacementReaction()
+						statusText = fmt.Sprintf("Displacement reaction (%.3g, %.3g, %.3g), %s", r[0], r[1], r[2], statusText)
+					}
+					if !result.Balanced() {
+						statusText = fmt.Sprintf("Warning: equilibrium error %.1e, %s", result.EquilibriumError(), statusText)
+						statusColor = rl.Orange
+					}
+				}
+				rl.DrawText(statusText,
+					int32(float32(rl.GetScreenWidth())-padding-inputWidth-padding)-rl.MeasureText(statusText, statusTextSize),
+					int32(float32(rl.GetScreenHeight())-padding-inputHeight/2)-statusTextSize/2,
+					statusTextSize, statusColor,
 				)
-				deformedBody = fem.ApplyForce(yungaModule.Value, poissonRatio.Value, pressure.Value)
-				running = 0
 			}
 		}
 		rl.EndDrawing()
 	}
 }
 
+// drawReactions draws resultant reactions of constrained faces with moments about the body center, applied load and
+// equilibrium error
+func drawReactions(result *fem.Result, x, y int32) {
+	const textSize = 18
+	faceNames := [6]string{"x-", "x+", "y-", "y+", "z-", "z+"}
+
+	size := result.Mesh().Size()
+	center := [3]float64{size[0] / 2, size[1] / 2, size[2] / 2}
+	lines := []string{"Reactions (moments about center):"}
+	for _, r := range result.FaceReactions(center) {
+		face := "nodes"
+		if r.Side >= 0 {
+			face = faceNames[r.Side]
+		}
+		lines = append(lines, fmt.Sprintf("%s: F (%.3g, %.3g, %.3g), M (%.3g, %.3g, %.3g)", face,
+			r.Force[0], r.Force[1], r.Force[2], r.Moment[0], r.Moment[1], r.Moment[2]))
+	}
+	load := result.AppliedLoad()
+	lines = append(lines,
+		fmt.Sprintf("Applied load: (%.3g, %.3g, %.3g)", load[0], load[1], load[2]),
+		fmt.Sprintf("Equilibrium error: %.2e", result.EquilibriumError()),
+	)
+
+	for i, line := range lines {
+		color := rl.DarkGray
+		if i == len(lines)-1 && !result.Balanced() {
+			color = rl.Red
+		}
+		rl.DrawText(line, x, y+int32(i)*(textSize+4), textSize, color)
+	}
+}
+
+// materialColors are colors of edges of elements by their material, the first one is for the main material
+var materialColors = []rl.Color{rl.Gray, rl.Brown, rl.DarkPurple, rl.Gold, rl.DarkBlue, rl.Lime, rl.Pink, rl.Beige}
+
+// drawMaterialEdges draws edges of boundary sides of elements colored by their material
+func drawMaterialEdges(mesh *fem.Mesh, materials *fem.Materials, origin rl.Vector3) {
+	for _, es := range mesh.BoundarySides() {
+		clr := materialColors[materials.Elements[es.Element]%len(materialColors)]
+		side := mesh.Side(es)
+		// Middle point i lies between corners i and i + 1
+		for i := range 4 {
+			corner, middle, next := transformPoint(side[i], origin), transformPoint(side[4+i], origin), transformPoint(side[(i+1)%4], origin)
+			rl.DrawLine3D(corner, middle, clr)
+			rl.DrawLine3D(middle, next, clr)
+		}
+	}
+}
+
+// nextFixedAxes cycles fixed axes of the side through all, normal only, tangential only and not fixed
+func nextFixedAxes(axes fem.Axes, side int) fem.Axes {
+	normal := fem.NormalAxis(side)
+	switch axes {
+	case 0:
+		return fem.AxesAll
+	case fem.AxesAll:
+		return normal
+	case normal:
+		return fem.AxesAll &^ normal
+	default:
+		return 0
+	}
+}
+
+// fixedAxesColor returns color of the side fixed along axes
+func fixedAxesColor(axes fem.Axes, side int) rl.Color {
+	normal := fem.NormalAxis(side)
+	switch axes {
+	case fem.AxesAll:
+		return rl.Blue
+	case normal:
+		return rl.Violet
+	case fem.AxesAll &^ normal:
+		return rl.DarkGreen
+	default:
+		return rl.SkyBlue
+	}
+}
+
 func transformPoint(p [3]float64, origin rl.Vector3) rl.Vector3 {
 	return rl.Vector3Subtract(rl.NewVector3(float32(p[0]), float32(p[2]), float32(p[1])), origin)
 }